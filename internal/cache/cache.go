@@ -0,0 +1,402 @@
+// Package cache provides persistent, thread-safe storage of model test results.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"llm-radar/internal/models"
+)
+
+// FormatVersion is the version of the on-disk cache format.
+// Files written with a different version are ignored on load.
+// Version 2 keys entries by fingerprint instead of model name.
+const FormatVersion = 2
+
+// ============================================================================
+// CACHE STRUCTURES
+// ============================================================================
+
+// ResultCache stores model results in memory and persists them to disk.
+// Entries are keyed by fingerprint, so runs with different prompts or probes
+// keep separate entries for the same model.
+// It is safe for concurrent use by multiple workers.
+type ResultCache struct {
+	path    string
+	expiry  time.Duration
+	ttls    map[string]time.Duration
+	entries map[string]models.CachedResult
+	dropped map[string]time.Time // CachedAt of removed entries, not merged back on Save
+	stats   Stats
+	mu      sync.RWMutex
+}
+
+// Key identifies the conditions under which a result was produced.
+// A cached entry is only reused when every field matches.
+type Key struct {
+	Model           string
+	Prompt          string
+	Probe           string
+	KBHash          string
+	OpencodeVersion string
+	Samples         int
+}
+
+// Fingerprint returns a stable hash of all key fields.
+func (k Key) Fingerprint() string {
+	parts := []string{k.Model, k.Prompt, k.Probe, k.KBHash, k.OpencodeVersion}
+	if k.Samples > 1 {
+		// Single-probe keys stay unchanged.
+		parts = append(parts, strconv.Itoa(k.Samples))
+	}
+	return hashParts(parts)
+}
+
+// scope hashes what a run asks of a model, leaving out the KB and opencode
+// versions. An entry with the same scope but another fingerprint was
+// produced by an older KB or opencode and can never be reused.
+func (k Key) scope() string {
+	return hashParts([]string{k.Model, k.Prompt, k.Probe, strconv.Itoa(k.Samples)})
+}
+
+func hashParts(parts []string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Stats counts cache lookups during a run.
+type Stats struct {
+	Hits          int `json:"hits"`
+	Misses        int `json:"misses"`
+	Invalidations int `json:"invalidations"`
+	Expired       int `json:"expired"`
+}
+
+// String formats the stats for terminal output.
+func (s Stats) String() string {
+	return fmt.Sprintf("%d hits, %d misses, %d invalidados, %d expirados",
+		s.Hits, s.Misses, s.Invalidations, s.Expired)
+}
+
+// fileFormat is the versioned JSON document written to disk.
+type fileFormat struct {
+	Version   int                            `json:"version"`
+	UpdatedAt time.Time                      `json:"updated_at"`
+	Entries   map[string]models.CachedResult `json:"entries"`
+}
+
+// New creates an empty cache backed by the file at path.
+// expiry is the lifetime of entries whose category has no specific TTL.
+func New(path string, expiry time.Duration) *ResultCache {
+	return &ResultCache{
+		path:    path,
+		expiry:  expiry,
+		ttls:    make(map[string]time.Duration),
+		entries: make(map[string]models.CachedResult),
+		dropped: make(map[string]time.Time),
+	}
+}
+
+// SetCategoryTTLs overrides the expiry for specific categories.
+// A TTL of zero means results of that category are never cached.
+func (c *ResultCache) SetCategoryTTLs(ttls map[string]time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttls = make(map[string]time.Duration, len(ttls))
+	for cat, ttl := range ttls {
+		c.ttls[cat] = ttl
+	}
+}
+
+// ttlFor returns the lifetime for a category. Callers must hold c.mu.
+func (c *ResultCache) ttlFor(category string) time.Duration {
+	if ttl, ok := c.ttls[category]; ok {
+		return ttl
+	}
+	return c.expiry
+}
+
+// ============================================================================
+// IN-MEMORY ACCESS
+// ============================================================================
+
+// Get returns the cached result for a key if present and fresh. On a miss,
+// entries of the same model, prompt and probe left by an older KB or
+// opencode version are dropped and counted as invalidated.
+func (c *ResultCache) Get(key Key) (models.ModelResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fp := key.Fingerprint()
+	entry, ok := c.entries[fp]
+	switch {
+	case !ok:
+		c.invalidate(key.scope())
+		c.stats.Misses++
+		return models.ModelResult{}, false
+	case entry.IsExpired():
+		c.drop(fp)
+		c.stats.Expired++
+		c.stats.Misses++
+		return models.ModelResult{}, false
+	}
+
+	c.stats.Hits++
+	return entry.Result, true
+}
+
+// invalidate drops the entries of scope, all of which have a stale
+// fingerprint. Callers must hold c.mu.
+func (c *ResultCache) invalidate(scope string) {
+	for fp, entry := range c.entries {
+		if entry.Scope == scope {
+			c.drop(fp)
+			c.stats.Invalidations++
+		}
+	}
+}
+
+// drop removes an entry so that Save does not restore it from disk. A newer
+// entry written by another process is still merged. Callers must hold c.mu.
+func (c *ResultCache) drop(fp string) {
+	if entry, ok := c.entries[fp]; ok {
+		c.dropped[fp] = entry.CachedAt
+	}
+	delete(c.entries, fp)
+}
+
+// Set stores a fresh result for a key, expiring according to its category.
+// Results whose category has a zero TTL are not stored.
+func (c *ResultCache) Set(key Key, res models.ModelResult) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fp := key.Fingerprint()
+	ttl := c.ttlFor(res.Category)
+	if ttl <= 0 {
+		c.drop(fp)
+		return
+	}
+
+	c.entries[fp] = models.CachedResult{
+		Result:      res,
+		Fingerprint: fp,
+		Scope:       key.scope(),
+		CachedAt:    now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	delete(c.dropped, fp)
+}
+
+// Stats returns a snapshot of the lookup counters.
+func (c *ResultCache) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stats
+}
+
+// Len returns the number of entries currently held in memory.
+func (c *ResultCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// ============================================================================
+// PERSISTENCE
+// ============================================================================
+
+// Load reads the cache file into memory, dropping expired entries.
+// A missing file or a file with an unknown format version is not an error.
+func (c *ResultCache) Load() error {
+	unlock, err := c.lock(syscall.LOCK_SH)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := c.readFile()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for fp, entry := range entries {
+		if entry.IsExpired() {
+			c.stats.Expired++
+			continue
+		}
+		c.entries[fp] = entry
+	}
+	return nil
+}
+
+// SaveResults records the given results and writes the cache to disk.
+// keyFn builds the cache key for each result. Results that were served from
+// the cache keep their original timestamps, so reusing an entry never
+// extends its lifetime.
+// Skipped results were never probed and are not cached.
+func (c *ResultCache) SaveResults(results []models.ModelResult, keyFn func(model string) Key) error {
+	for _, res := range results {
+		if res.Skipped {
+			continue
+		}
+		key := keyFn(res.Model)
+		c.mu.RLock()
+		existing, ok := c.entries[key.Fingerprint()]
+		c.mu.RUnlock()
+
+		if ok && existing.Result.Timestamp == res.Timestamp {
+			continue
+		}
+		c.Set(key, res)
+	}
+
+	return c.Save()
+}
+
+// Save writes the in-memory cache to disk atomically.
+// Entries written concurrently by another process are merged, keeping the
+// most recent result for each fingerprint. Entries this cache dropped as
+// expired or invalidated stay dropped.
+func (c *ResultCache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório do cache: %w", err)
+	}
+
+	unlock, err := c.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	onDisk, err := c.readFile()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	for fp, entry := range onDisk {
+		if dropped, ok := c.dropped[fp]; entry.IsExpired() || (ok && !entry.CachedAt.After(dropped)) {
+			continue
+		}
+		if current, ok := c.entries[fp]; !ok || entry.CachedAt.After(current.CachedAt) {
+			c.entries[fp] = entry
+		}
+	}
+
+	doc := fileFormat{
+		Version:   FormatVersion,
+		UpdatedAt: time.Now(),
+		Entries:   make(map[string]models.CachedResult, len(c.entries)),
+	}
+	for fp, entry := range c.entries {
+		if !entry.IsExpired() {
+			doc.Entries[fp] = entry
+		}
+	}
+	c.mu.Unlock()
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao serializar cache: %w", err)
+	}
+
+	return writeFileAtomic(c.path, data, 0644)
+}
+
+// readFile parses the cache file. Callers must hold the file lock.
+func (c *ResultCache) readFile() (map[string]models.CachedResult, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler cache: %w", err)
+	}
+
+	var doc fileFormat
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("erro ao parsear cache: %w", err)
+	}
+	if doc.Version != FormatVersion {
+		return nil, nil
+	}
+	return doc.Entries, nil
+}
+
+// lock acquires an advisory lock on a sidecar file next to the cache,
+// so concurrent runs (e.g. from cron) never interleave reads and writes.
+func (c *ResultCache) lock(how int) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório do cache: %w", err)
+	}
+
+	f, err := os.OpenFile(c.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir lock do cache: %w", err)
+	}
+
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("erro ao travar cache: %w", err)
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory
+// and renames it over path, so readers never observe a partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("erro ao escrever cache: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("erro ao sincronizar cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("erro ao fechar cache: %w", err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("erro ao ajustar permissões do cache: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("erro ao substituir cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"llm-radar/internal/models"
+)
+
+func newTestResult(model string) models.ModelResult {
+	return models.ModelResult{
+		Model:     model,
+		Provider:  "test",
+		Category:  models.CategoryAvailable,
+		Reason:    "Modelo disponível",
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+	}
+}
+
+func newTestKey(model string) Key {
+	return Key{Model: model, Prompt: "prompt", KBHash: "kb", OpencodeVersion: "1.0.0"}
+}
+
+func TestSetAndGet(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "results.json"), time.Hour)
+
+	if _, ok := c.Get(newTestKey("test/model")); ok {
+		t.Fatal("Expected miss on empty cache")
+	}
+
+	c.Set(newTestKey("test/model"), newTestResult("test/model"))
+
+	res, ok := c.Get(newTestKey("test/model"))
+	if !ok {
+		t.Fatal("Expected hit after Set")
+	}
+	if res.Category != models.CategoryAvailable {
+		t.Errorf("Category mismatch: got %q", res.Category)
+	}
+}
+
+func TestGetExpired(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "results.json"), -time.Second)
+	c.Set(newTestKey("test/model"), newTestResult("test/model"))
+
+	if _, ok := c.Get(newTestKey("test/model")); ok {
+		t.Error("Expired entry should not be returned")
+	}
+}
+
+func TestSaveAndLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "results.json")
+
+	c := New(path, time.Hour)
+	if err := c.SaveResults([]models.ModelResult{
+		newTestResult("test/a"),
+		newTestResult("test/b"),
+	}, newTestKey); err != nil {
+		t.Fatalf("SaveResults failed: %v", err)
+	}
+
+	loaded := New(path, time.Hour)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", loaded.Len())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc fileFormat
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Cache file is not valid JSON: %v", err)
+	}
+	if doc.Version != FormatVersion {
+		t.Errorf("Expected version %d, got %d", FormatVersion, doc.Version)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("Temporary files left behind: %v", matches)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "missing.json"), time.Hour)
+	if err := c.Load(); err != nil {
+		t.Errorf("Missing file should not be an error: %v", err)
+	}
+}
+
+func TestLoadIgnoresOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	content := `{"version": 999, "entries": {"test/a": {"result": {"model": "test/a"}}}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(path, time.Hour)
+	if err := c.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Entries from unknown version should be ignored, got %d", c.Len())
+	}
+}
+
+func TestSaveResultsKeepsCachedTimestamps(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "results.json"), time.Hour)
+	c.Set(newTestKey("test/a"), newTestResult("test/a"))
+
+	c.mu.RLock()
+	before := c.entries[newTestKey("test/a").Fingerprint()]
+	c.mu.RUnlock()
+
+	reused, _ := c.Get(newTestKey("test/a"))
+	reused.Reason += " (cached)"
+
+	if err := c.SaveResults([]models.ModelResult{reused}, newTestKey); err != nil {
+		t.Fatal(err)
+	}
+
+	c.mu.RLock()
+	after := c.entries[newTestKey("test/a").Fingerprint()]
+	c.mu.RUnlock()
+
+	if !after.CachedAt.Equal(before.CachedAt) {
+		t.Error("Reusing a cached result should not refresh its CachedAt")
+	}
+	if after.Result.Reason != before.Result.Reason {
+		t.Errorf("Reason should not be rewritten: got %q", after.Result.Reason)
+	}
+}
+
+func TestSaveMergesConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+
+	first := New(path, time.Hour)
+	second := New(path, time.Hour)
+
+	first.Set(newTestKey("test/a"), newTestResult("test/a"))
+	second.Set(newTestKey("test/b"), newTestResult("test/b"))
+
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := New(path, time.Hour)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []string{"test/a", "test/b"} {
+		if _, ok := loaded.Get(newTestKey(model)); !ok {
+			t.Errorf("Expected %s after merged saves", model)
+		}
+	}
+}
+
+func TestConcurrentSet(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "results.json"), time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			model := fmt.Sprintf("test/model-%d", i)
+			c.Set(newTestKey(model), newTestResult(model))
+			c.Get(newTestKey(model))
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Len() != 50 {
+		t.Errorf("Expected 50 entries, got %d", c.Len())
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	base := newTestKey("test/model")
+	if base.Fingerprint() != newTestKey("test/model").Fingerprint() {
+		t.Error("Fingerprint should be deterministic")
+	}
+
+	variants := []Key{
+		{Model: "test/other", Prompt: base.Prompt, KBHash: base.KBHash, OpencodeVersion: base.OpencodeVersion},
+		{Model: base.Model, Prompt: "other prompt", KBHash: base.KBHash, OpencodeVersion: base.OpencodeVersion},
+		{Model: base.Model, Prompt: base.Prompt, Probe: "en", KBHash: base.KBHash, OpencodeVersion: base.OpencodeVersion},
+		{Model: base.Model, Prompt: base.Prompt, KBHash: "other-kb", OpencodeVersion: base.OpencodeVersion},
+		{Model: base.Model, Prompt: base.Prompt, KBHash: base.KBHash, OpencodeVersion: "2.0.0"},
+	}
+	for _, v := range variants {
+		if v.Fingerprint() == base.Fingerprint() {
+			t.Errorf("Fingerprint should change for %+v", v)
+		}
+	}
+}
+
+func TestGetInvalidatesStaleFingerprint(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "results.json"), time.Hour)
+	c.Set(newTestKey("test/model"), newTestResult("test/model"))
+
+	stale := newTestKey("test/model")
+	stale.KBHash = "new-kb"
+
+	if _, ok := c.Get(stale); ok {
+		t.Fatal("Entry with a different fingerprint should not be returned")
+	}
+	if _, ok := c.Get(newTestKey("test/model")); ok {
+		t.Error("Invalidated entry should be removed")
+	}
+
+	stats := c.Stats()
+	if stats.Invalidations != 1 {
+		t.Errorf("Expected 1 invalidation, got %d", stats.Invalidations)
+	}
+	if stats.Misses != 2 {
+		t.Errorf("Expected 2 misses, got %d", stats.Misses)
+	}
+}
+
+func TestDifferentPromptsKeepSeparateEntries(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "results.json"), time.Hour)
+	other := newTestKey("test/model")
+	other.Probe = "en"
+	other.Prompt = "another prompt"
+
+	c.Set(newTestKey("test/model"), newTestResult("test/model"))
+	c.Set(other, newTestResult("test/model"))
+
+	if _, ok := c.Get(newTestKey("test/model")); !ok {
+		t.Error("Expected a hit for the default probe")
+	}
+	if _, ok := c.Get(other); !ok {
+		t.Error("Expected a hit for the other probe")
+	}
+	if stats := c.Stats(); stats.Invalidations != 0 || c.Len() != 2 {
+		t.Errorf("Expected two coexisting entries, got %d (%+v)", c.Len(), stats)
+	}
+}
+
+func TestInvalidationPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	old := New(path, time.Hour)
+	old.Set(newTestKey("test/model"), newTestResult("test/model"))
+	if err := old.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := newTestKey("test/model")
+	stale.KBHash = "new-kb"
+
+	c := New(path, time.Hour)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	c.Get(stale)
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := New(path, time.Hour)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Len() != 0 {
+		t.Errorf("Invalidated entry should not be written back, got %d entries", reloaded.Len())
+	}
+}
+
+func TestStatsCountHits(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "results.json"), time.Hour)
+	c.Set(newTestKey("test/model"), newTestResult("test/model"))
+
+	c.Get(newTestKey("test/model"))
+	c.Get(newTestKey("test/model"))
+	c.Get(newTestKey("test/missing"))
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestCategoryTTLs(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "results.json"), time.Hour)
+	c.SetCategoryTTLs(map[string]time.Duration{
+		models.CategoryRateLimited: 0,
+		models.CategoryAuthFailed:  72 * time.Hour,
+	})
+
+	limited := newTestResult("test/limited")
+	limited.Category = models.CategoryRateLimited
+	c.Set(newTestKey("test/limited"), limited)
+
+	if _, ok := c.Get(newTestKey("test/limited")); ok {
+		t.Error("Category with zero TTL should not be cached")
+	}
+
+	auth := newTestResult("test/auth")
+	auth.Category = models.CategoryAuthFailed
+	c.Set(newTestKey("test/auth"), auth)
+	c.Set(newTestKey("test/default"), newTestResult("test/default"))
+
+	c.mu.RLock()
+	authEntry := c.entries[newTestKey("test/auth").Fingerprint()]
+	defaultEntry := c.entries[newTestKey("test/default").Fingerprint()]
+	c.mu.RUnlock()
+
+	if got := authEntry.ExpiresAt.Sub(authEntry.CachedAt); got != 72*time.Hour {
+		t.Errorf("Expected 72h TTL for AUTH_FAILED, got %s", got)
+	}
+	if got := defaultEntry.ExpiresAt.Sub(defaultEntry.CachedAt); got != time.Hour {
+		t.Errorf("Expected default TTL for unlisted category, got %s", got)
+	}
+}
@@ -0,0 +1,259 @@
+// Package checkpoint saves the results of a run as they arrive, so an
+// interrupted run can be resumed later without probing finished models again.
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"llm-radar/internal/models"
+)
+
+// fileExt is the extension of run files. Each file holds a JSON header line
+// followed by one ModelResult per line.
+const fileExt = ".jsonl"
+
+// ============================================================================
+// RUN FILE
+// ============================================================================
+
+// Header describes a run: its queue and the conditions a resume must reuse.
+type Header struct {
+	RunID   string    `json:"run_id"`
+	Started time.Time `json:"started"`
+	Prompt  string    `json:"prompt"`
+	Probe   string    `json:"probe,omitempty"`
+	KBHash  string    `json:"kb_hash"`
+	Models  []string  `json:"models"`
+}
+
+// Run is a checkpoint loaded from disk.
+type Run struct {
+	Header
+	Results []models.ModelResult
+}
+
+// Remaining returns the queued models without a saved result, in queue order.
+func (r Run) Remaining() []string {
+	done := make(map[string]bool, len(r.Results))
+	for _, res := range r.Results {
+		done[res.Model] = true
+	}
+
+	var remaining []string
+	for _, m := range r.Models {
+		if !done[m] {
+			remaining = append(remaining, m)
+		}
+	}
+	return remaining
+}
+
+// DefaultDir returns the directory where run files are kept.
+func DefaultDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "opencode", "runs")
+}
+
+// NewID returns a run ID derived from the start time.
+func NewID(t time.Time) string {
+	return t.Format("20060102-150405")
+}
+
+func path(dir, id string) string {
+	return filepath.Join(dir, id+fileExt)
+}
+
+// ============================================================================
+// WRITER
+// ============================================================================
+
+// Writer appends results to a run file. It is safe for concurrent use.
+type Writer struct {
+	id   string
+	path string
+	f    *os.File
+	mu   sync.Mutex
+}
+
+// Create starts a new run file for h in dir. If h.RunID is taken, a numeric
+// suffix is added; Writer.ID returns the ID actually used.
+func Create(dir string, h Header) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de checkpoints: %w", err)
+	}
+
+	base := h.RunID
+	var f *os.File
+	for n := 2; ; n++ {
+		var err error
+		f, err = os.OpenFile(path(dir, h.RunID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) || n > 100 {
+			return nil, fmt.Errorf("erro ao criar checkpoint: %w", err)
+		}
+		h.RunID = fmt.Sprintf("%s-%d", base, n)
+	}
+	p := path(dir, h.RunID)
+
+	w := &Writer{id: h.RunID, path: p, f: f}
+	if err := w.writeLine(h); err != nil {
+		f.Close()
+		os.Remove(p)
+		return nil, err
+	}
+	return w, nil
+}
+
+// Reopen appends to the run file of an existing run.
+func Reopen(dir string, run Run) (*Writer, error) {
+	p := path(dir, run.RunID)
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir checkpoint: %w", err)
+	}
+
+	// Terminate a partial last line so new results start on their own line.
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			f.Write([]byte{'\n'})
+		}
+	}
+	return &Writer{id: run.RunID, path: p, f: f}, nil
+}
+
+// ID returns the run ID.
+func (w *Writer) ID() string {
+	return w.id
+}
+
+// Append saves a finished result.
+func (w *Writer) Append(res models.ModelResult) error {
+	return w.writeLine(res)
+}
+
+func (w *Writer) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("erro ao gravar checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file, keeping it for a later resume.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+// Finish closes and deletes the file of a completed run.
+func (w *Writer) Finish() error {
+	w.Close()
+	return remove(w.path)
+}
+
+// ============================================================================
+// LOADING
+// ============================================================================
+
+// Load reads the run id from dir, or the most recent run when id is empty.
+// Lines that fail to parse, such as a result cut short by a killed process,
+// are skipped; their models count as remaining.
+func Load(dir, id string) (Run, error) {
+	if id == "" {
+		var err error
+		if id, err = Latest(dir); err != nil {
+			return Run{}, err
+		}
+	}
+
+	f, err := os.Open(path(dir, id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Run{}, fmt.Errorf("execução %q não encontrada em %s", id, dir)
+	}
+	if err != nil {
+		return Run{}, fmt.Errorf("erro ao abrir checkpoint: %w", err)
+	}
+	defer f.Close()
+
+	var run Run
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+
+		if lineNo == 1 {
+			if err := json.Unmarshal(line, &run.Header); err != nil || run.RunID == "" {
+				return Run{}, fmt.Errorf("checkpoint inválido: %s", id)
+			}
+			continue
+		}
+
+		var res models.ModelResult
+		if err := json.Unmarshal(line, &res); err != nil || res.Model == "" {
+			continue
+		}
+		run.Results = append(run.Results, res)
+	}
+	if err := scanner.Err(); err != nil {
+		return Run{}, fmt.Errorf("erro ao ler checkpoint: %w", err)
+	}
+	if lineNo == 0 {
+		return Run{}, fmt.Errorf("checkpoint vazio: %s", id)
+	}
+	return run, nil
+}
+
+// Remove deletes the run file of id.
+func Remove(dir, id string) error {
+	return remove(path(dir, id))
+}
+
+func remove(p string) error {
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("erro ao remover checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Latest returns the ID of the most recent run in dir.
+func Latest(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("erro ao listar checkpoints: %w", err)
+	}
+
+	var ids []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasSuffix(name, fileExt) {
+			ids = append(ids, strings.TrimSuffix(name, fileExt))
+		}
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("nenhuma execução interrompida em %s", dir)
+	}
+
+	// IDs from NewID sort chronologically.
+	sort.Strings(ids)
+	return ids[len(ids)-1], nil
+}
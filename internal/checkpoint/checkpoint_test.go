@@ -0,0 +1,145 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llm-radar/internal/models"
+)
+
+func testHeader(id string) Header {
+	return Header{
+		RunID:   id,
+		Started: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Prompt:  "p",
+		Probe:   "default",
+		KBHash:  "abc",
+		Models:  []string{"a/1", "b/2", "c/3"},
+	}
+}
+
+func TestCreateAppendLoad(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Create(dir, testHeader("run1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append(models.ModelResult{Model: "b/2", Category: models.CategoryAvailable}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	run, err := Load(dir, "run1")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if run.Prompt != "p" || run.KBHash != "abc" || len(run.Results) != 1 {
+		t.Errorf("Unexpected run: %+v", run)
+	}
+	remaining := run.Remaining()
+	if len(remaining) != 2 || remaining[0] != "a/1" || remaining[1] != "c/3" {
+		t.Errorf("Expected [a/1 c/3] remaining, got %v", remaining)
+	}
+}
+
+func TestCreateAvoidsIDCollision(t *testing.T) {
+	dir := t.TempDir()
+	first, err := Create(dir, testHeader("run1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	second, err := Create(dir, testHeader("run1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	if second.ID() != "run1-2" {
+		t.Errorf("Expected run1-2, got %s", second.ID())
+	}
+}
+
+func TestReopenAfterPartialLine(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Create(dir, testHeader("run1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Append(models.ModelResult{Model: "a/1"})
+	w.Close()
+
+	// Simulate a process killed while writing a result.
+	f, _ := os.OpenFile(filepath.Join(dir, "run1.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"model":"b/`)
+	f.Close()
+
+	run, err := Load(dir, "run1")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("Expected the partial result to be skipped, got %d results", len(run.Results))
+	}
+
+	w, err = Reopen(dir, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Append(models.ModelResult{Model: "c/3"})
+	w.Close()
+
+	run, err = Load(dir, "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining := run.Remaining(); len(remaining) != 1 || remaining[0] != "b/2" {
+		t.Errorf("Expected only b/2 remaining, got %v", remaining)
+	}
+}
+
+func TestLoadLatest(t *testing.T) {
+	dir := t.TempDir()
+	for _, id := range []string{"20260101-120000", "20260301-090000", "20260201-080000"} {
+		w, err := Create(dir, testHeader(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+	}
+
+	run, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.RunID != "20260301-090000" {
+		t.Errorf("Expected the most recent run, got %s", run.RunID)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir, ""); err == nil {
+		t.Error("Expected error with no saved runs")
+	}
+	if _, err := Load(dir, "nope"); err == nil {
+		t.Error("Expected error for unknown run")
+	}
+}
+
+func TestFinishRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Create(dir, testHeader("run1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "run1.jsonl")); !os.IsNotExist(err) {
+		t.Error("Finish should delete the run file")
+	}
+}
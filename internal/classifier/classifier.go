@@ -2,7 +2,9 @@
 package classifier
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"llm-radar/internal/kb"
 	"llm-radar/internal/models"
@@ -16,7 +18,8 @@ type Result struct {
 }
 
 // Classify determines the category, reason, and icon for a model's test result.
-func Classify(model string, exitCode int, output string, compiledKB kb.Compiled) Result {
+// timeout is the one the probe ran with, reported in TIMEOUT reasons.
+func Classify(model string, exitCode int, output string, timeout time.Duration, compiledKB kb.Compiled) Result {
 	// Check for not found first (takes priority)
 	if compiledKB.NotFoundRe.MatchString(output) {
 		return Result{
@@ -30,7 +33,7 @@ func Classify(model string, exitCode int, output string, compiledKB kb.Compiled)
 	if exitCode == 124 || compiledKB.TimeoutRe.MatchString(output) {
 		return Result{
 			Category: models.CategoryTimeout,
-			Reason:   "Timeout (20s)",
+			Reason:   fmt.Sprintf("Timeout (%s)", timeout),
 			Icon:     models.CategoryIcons[models.CategoryTimeout],
 		}
 	}
@@ -116,3 +119,13 @@ func Classify(model string, exitCode int, output string, compiledKB kb.Compiled)
 		Icon:     models.CategoryIcons[models.CategoryError],
 	}
 }
+
+// FromCategory builds a Result for a category already determined by the
+// probe backend, e.g. from an HTTP status code.
+func FromCategory(category, reason string) Result {
+	return Result{
+		Category: category,
+		Reason:   reason,
+		Icon:     models.CategoryIcons[category],
+	}
+}
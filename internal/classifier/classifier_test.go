@@ -2,11 +2,15 @@ package classifier
 
 import (
 	"testing"
+	"time"
 
 	"llm-radar/internal/kb"
 	"llm-radar/internal/models"
 )
 
+// testTimeout is the probe timeout passed to Classify.
+const testTimeout = 20 * time.Second
+
 func getTestKB(t *testing.T) kb.Compiled {
 	compiled, err := kb.Compile(kb.DefaultConfig())
 	if err != nil {
@@ -27,7 +31,7 @@ func TestClassifyNotFound(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := Classify("any/model", 1, tt.output, compiled)
+		result := Classify("any/model", 1, tt.output, testTimeout, compiled)
 		if result.Category != models.CategoryNotFound {
 			t.Errorf("Expected NOT_FOUND for %q, got %s", tt.output, result.Category)
 		}
@@ -38,13 +42,19 @@ func TestClassifyTimeout(t *testing.T) {
 	compiled := getTestKB(t)
 
 	// Exit code 124 indicates timeout
-	result := Classify("any/model", 124, "", compiled)
+	result := Classify("any/model", 124, "", testTimeout, compiled)
 	if result.Category != models.CategoryTimeout {
 		t.Errorf("Expected TIMEOUT for exit code 124, got %s", result.Category)
 	}
+	if result.Reason != "Timeout (20s)" {
+		t.Errorf("Expected the probe timeout in the reason, got %q", result.Reason)
+	}
+	if result = Classify("any/model", 124, "", time.Minute, compiled); result.Reason != "Timeout (1m0s)" {
+		t.Errorf("Expected the effective timeout in the reason, got %q", result.Reason)
+	}
 
 	// Timeout in output
-	result = Classify("any/model", 1, "connection timed out", compiled)
+	result = Classify("any/model", 1, "connection timed out", testTimeout, compiled)
 	if result.Category != models.CategoryTimeout {
 		t.Errorf("Expected TIMEOUT for 'timed out' output, got %s", result.Category)
 	}
@@ -54,13 +64,13 @@ func TestClassifyFreeModel(t *testing.T) {
 	compiled := getTestKB(t)
 
 	// Successful free model
-	result := Classify("opencode/big-pickle", 0, "2, 3, 5", compiled)
+	result := Classify("opencode/big-pickle", 0, "2, 3, 5", testTimeout, compiled)
 	if result.Category != models.CategoryFree {
 		t.Errorf("Expected FREE, got %s", result.Category)
 	}
 
 	// Failed free model
-	result = Classify("opencode/big-pickle", 1, "error occurred", compiled)
+	result = Classify("opencode/big-pickle", 1, "error occurred", testTimeout, compiled)
 	if result.Category != models.CategoryFreeError {
 		t.Errorf("Expected FREE_ERROR, got %s", result.Category)
 	}
@@ -70,13 +80,13 @@ func TestClassifyFreeSuffix(t *testing.T) {
 	compiled := getTestKB(t)
 
 	// Model with -free suffix that succeeds
-	result := Classify("unknown/model-free", 0, "primos", compiled)
+	result := Classify("unknown/model-free", 0, "primos", testTimeout, compiled)
 	if result.Category != models.CategoryFree {
 		t.Errorf("Expected FREE for -free suffix, got %s", result.Category)
 	}
 
 	// Model with -free suffix that fails
-	result = Classify("unknown/model-free", 1, "error", compiled)
+	result = Classify("unknown/model-free", 1, "error", testTimeout, compiled)
 	if result.Category != models.CategoryFreeError {
 		t.Errorf("Expected FREE_ERROR for failed -free model, got %s", result.Category)
 	}
@@ -87,13 +97,13 @@ func TestClassifyFreeTierProvider(t *testing.T) {
 	compiled := getTestKB(t)
 
 	// Successful model from groq (free tier provider)
-	result := Classify("groq/llama-3.1", 0, "2, 3, 5", compiled)
+	result := Classify("groq/llama-3.1", 0, "2, 3, 5", testTimeout, compiled)
 	if result.Category != models.CategoryFreeLimited {
 		t.Errorf("Expected FREE_LIMITED for groq, got %s", result.Category)
 	}
 
 	// Successful model from cerebras (free tier provider)
-	result = Classify("cerebras/llama-3.1", 0, "primos", compiled)
+	result = Classify("cerebras/llama-3.1", 0, "primos", testTimeout, compiled)
 	if result.Category != models.CategoryFreeLimited {
 		t.Errorf("Expected FREE_LIMITED for cerebras, got %s", result.Category)
 	}
@@ -103,7 +113,7 @@ func TestClassifyAvailable(t *testing.T) {
 	compiled := getTestKB(t)
 
 	// Generic successful model
-	result := Classify("unknown/model", 0, "2, 3, 5", compiled)
+	result := Classify("unknown/model", 0, "2, 3, 5", testTimeout, compiled)
 	if result.Category != models.CategoryAvailable {
 		t.Errorf("Expected AVAILABLE, got %s", result.Category)
 	}
@@ -122,7 +132,7 @@ func TestClassifyAuthFailed(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := Classify("unknown/model", 1, tt.output, compiled)
+		result := Classify("unknown/model", 1, tt.output, testTimeout, compiled)
 		if result.Category != models.CategoryAuthFailed {
 			t.Errorf("Expected AUTH_FAILED for %q, got %s", tt.output, result.Category)
 		}
@@ -141,7 +151,7 @@ func TestClassifyNoQuota(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := Classify("unknown/model", 1, tt.output, compiled)
+		result := Classify("unknown/model", 1, tt.output, testTimeout, compiled)
 		if result.Category != models.CategoryNoQuota {
 			t.Errorf("Expected NO_QUOTA for %q, got %s", tt.output, result.Category)
 		}
@@ -160,7 +170,7 @@ func TestClassifyRateLimited(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := Classify("unknown/model", 1, tt.output, compiled)
+		result := Classify("unknown/model", 1, tt.output, testTimeout, compiled)
 		if result.Category != models.CategoryRateLimited {
 			t.Errorf("Expected RATE_LIMITED for %q, got %s", tt.output, result.Category)
 		}
@@ -171,7 +181,7 @@ func TestClassifyError(t *testing.T) {
 	compiled := getTestKB(t)
 
 	// Unknown error
-	result := Classify("unknown/model", 1, "something completely random", compiled)
+	result := Classify("unknown/model", 1, "something completely random", testTimeout, compiled)
 	if result.Category != models.CategoryError {
 		t.Errorf("Expected ERROR, got %s", result.Category)
 	}
@@ -180,7 +190,7 @@ func TestClassifyError(t *testing.T) {
 func TestClassifyResultHasIcon(t *testing.T) {
 	compiled := getTestKB(t)
 
-	result := Classify("opencode/big-pickle", 0, "2, 3, 5", compiled)
+	result := Classify("opencode/big-pickle", 0, "2, 3, 5", testTimeout, compiled)
 	if result.Icon == "" {
 		t.Error("Expected non-empty icon")
 	}
@@ -190,7 +200,7 @@ func TestClassifyPriorityNotFoundOverTimeout(t *testing.T) {
 	compiled := getTestKB(t)
 
 	// Not found should take priority even with timeout output
-	result := Classify("any/model", 124, "404 not found timeout", compiled)
+	result := Classify("any/model", 124, "404 not found timeout", testTimeout, compiled)
 	if result.Category != models.CategoryNotFound {
 		t.Errorf("Expected NOT_FOUND over TIMEOUT, got %s", result.Category)
 	}
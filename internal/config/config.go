@@ -0,0 +1,500 @@
+// Package config resolves the run configuration from defaults, YAML files,
+// environment variables and command-line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
+)
+
+// Config file names and environment prefix.
+const (
+	ProjectFileName = ".llm-radar.yaml"
+	UserFileName    = "config.yaml"
+	EnvPrefix       = "LLM_RADAR_"
+)
+
+// Sources, from lowest to highest precedence.
+const (
+	SourceDefault = "default"
+	SourceUser    = "user"
+	SourceProject = "project"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// ============================================================================
+// LAYERS AND SETTINGS
+// ============================================================================
+
+// Setting is a raw value from one layer. Value is a string, or a []string
+// for list keys.
+type Setting struct {
+	Value  any
+	Origin Origin
+}
+
+// Origin records where a value came from.
+type Origin struct {
+	Source string
+	// Detail is the file path, environment variable or flag name.
+	Detail string
+}
+
+// String formats the origin for `config show`.
+func (o Origin) String() string {
+	if o.Detail == "" {
+		return o.Source
+	}
+	return fmt.Sprintf("%s (%s)", o.Source, o.Detail)
+}
+
+// Layer maps config keys to settings from a single source.
+type Layer map[string]Setting
+
+// Resolved is the effective configuration and the origin of each key.
+type Resolved struct {
+	Config  models.RunConfig
+	Origins map[string]Origin
+}
+
+// ============================================================================
+// DEFAULTS
+// ============================================================================
+
+// Defaults returns the built-in run configuration.
+// A Concurrency of 0 means it is chosen from the number of CPUs.
+func Defaults() models.RunConfig {
+	homeDir, _ := os.UserHomeDir()
+	return models.RunConfig{
+		Prompt:         "Escreva apenas: 2, 3, 5",
+		Probe:          kb.DefaultProbe,
+		Timeout:        20 * time.Second,
+		Concurrency:    0,
+		Retries:        1,
+		MaxOutputKB:    64,
+		UseCache:       false,
+		CachePath:      filepath.Join(homeDir, ".config", "opencode", "cache", "results.json"),
+		HistoryPath:    filepath.Join(homeDir, ".config", "opencode", "history.json"),
+		CircuitBreaker: 2,
+		Samples:        1,
+		SampleInterval: time.Second,
+	}
+}
+
+// ============================================================================
+// FIELD TABLE
+// ============================================================================
+
+type field struct {
+	key  string
+	list bool
+	set  func(cfg *models.RunConfig, v any) error
+	get  func(cfg models.RunConfig) string
+}
+
+var fields = []field{
+	{
+		key: "prompt",
+		set: func(c *models.RunConfig, v any) error { c.Prompt = asString(v); return nil },
+		get: func(c models.RunConfig) string { return strconv.Quote(c.Prompt) },
+	},
+	{
+		key: "probe",
+		set: func(c *models.RunConfig, v any) error { c.Probe = asString(v); return nil },
+		get: func(c models.RunConfig) string { return c.Probe },
+	},
+	{
+		key: "timeout",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.Timeout, err = time.ParseDuration(asString(v))
+			if err == nil && c.Timeout <= 0 {
+				err = fmt.Errorf("deve ser > 0")
+			}
+			return err
+		},
+		get: func(c models.RunConfig) string { return c.Timeout.String() },
+	},
+	{
+		key: "deadline",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.Deadline, err = time.ParseDuration(asString(v))
+			if err == nil && c.Deadline < 0 {
+				err = fmt.Errorf("deve ser >= 0")
+			}
+			return err
+		},
+		get: func(c models.RunConfig) string { return c.Deadline.String() },
+	},
+	{
+		key: "concurrency",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.Concurrency, err = parseNonNegative(asString(v))
+			return err
+		},
+		get: func(c models.RunConfig) string {
+			if c.Concurrency == 0 {
+				return "auto"
+			}
+			return strconv.Itoa(c.Concurrency)
+		},
+	},
+	{
+		key: "retries",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.Retries, err = parseNonNegative(asString(v))
+			return err
+		},
+		get: func(c models.RunConfig) string { return strconv.Itoa(c.Retries) },
+	},
+	{
+		key: "max_output_kb",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.MaxOutputKB, err = parseNonNegative(asString(v))
+			return err
+		},
+		get: func(c models.RunConfig) string { return strconv.Itoa(c.MaxOutputKB) },
+	},
+	{
+		key: "cache",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.UseCache, err = strconv.ParseBool(asString(v))
+			return err
+		},
+		get: func(c models.RunConfig) string { return strconv.FormatBool(c.UseCache) },
+	},
+	{
+		key: "cache_path",
+		set: func(c *models.RunConfig, v any) error { c.CachePath = expandHome(asString(v)); return nil },
+		get: func(c models.RunConfig) string { return c.CachePath },
+	},
+	{
+		key: "history_path",
+		set: func(c *models.RunConfig, v any) error { c.HistoryPath = expandHome(asString(v)); return nil },
+		get: func(c models.RunConfig) string { return c.HistoryPath },
+	},
+	{
+		key: "serve",
+		set: func(c *models.RunConfig, v any) error { c.Serve = asString(v); return nil },
+		get: func(c models.RunConfig) string { return c.Serve },
+	},
+	{
+		key: "samples",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.Samples, err = parseNonNegative(asString(v))
+			if err == nil && c.Samples == 0 {
+				err = fmt.Errorf("deve ser pelo menos 1")
+			}
+			return err
+		},
+		get: func(c models.RunConfig) string { return strconv.Itoa(c.Samples) },
+	},
+	{
+		key: "sample_interval",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.SampleInterval, err = time.ParseDuration(asString(v))
+			if err == nil && c.SampleInterval < 0 {
+				err = fmt.Errorf("deve ser >= 0")
+			}
+			return err
+		},
+		get: func(c models.RunConfig) string { return c.SampleInterval.String() },
+	},
+	{
+		key: "circuit_breaker",
+		set: func(c *models.RunConfig, v any) (err error) {
+			c.CircuitBreaker, err = parseNonNegative(asString(v))
+			return err
+		},
+		get: func(c models.RunConfig) string { return strconv.Itoa(c.CircuitBreaker) },
+	},
+	{
+		key: "export",
+		set: func(c *models.RunConfig, v any) error { c.ExportFormat = asString(v); return nil },
+		get: func(c models.RunConfig) string { return c.ExportFormat },
+	},
+	{
+		key: "output",
+		set: func(c *models.RunConfig, v any) error { c.ExportPath = expandHome(asString(v)); return nil },
+		get: func(c models.RunConfig) string { return c.ExportPath },
+	},
+	{
+		key:  "providers",
+		list: true,
+		set:  func(c *models.RunConfig, v any) error { c.Providers = asList(v); return nil },
+		get:  func(c models.RunConfig) string { return formatList(c.Providers) },
+	},
+	{
+		key:  "include",
+		list: true,
+		set:  func(c *models.RunConfig, v any) error { c.Include = asList(v); return nil },
+		get:  func(c models.RunConfig) string { return formatList(c.Include) },
+	},
+	{
+		key:  "exclude",
+		list: true,
+		set:  func(c *models.RunConfig, v any) error { c.Exclude = asList(v); return nil },
+		get:  func(c models.RunConfig) string { return formatList(c.Exclude) },
+	},
+}
+
+func lookupField(key string) (field, bool) {
+	for _, f := range fields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// Keys returns every supported config key in display order.
+func Keys() []string {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.key
+	}
+	return keys
+}
+
+// ============================================================================
+// LOADING LAYERS
+// ============================================================================
+
+// UserConfigPath returns the user config file under $XDG_CONFIG_HOME
+// (or ~/.config when unset).
+func UserConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		homeDir, _ := os.UserHomeDir()
+		dir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(dir, "llm-radar", UserFileName)
+}
+
+// FindProjectConfig looks for ProjectFileName in dir and its parents.
+// It returns an empty string if none is found.
+func FindProjectConfig(dir string) string {
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadFile reads a YAML config file as a layer of the given source.
+// A missing file yields an empty layer.
+func LoadFile(path, source string) (Layer, error) {
+	layer := Layer{}
+	if path == "" {
+		return layer, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return layer, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler config %s: %w", path, err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("erro ao parsear config %s: %w", path, err)
+	}
+
+	origin := Origin{Source: source, Detail: path}
+	for key, v := range raw {
+		f, ok := lookupField(key)
+		if !ok {
+			return nil, fmt.Errorf("%s: chave desconhecida %q", path, key)
+		}
+
+		value, err := normalize(v, f.list)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+		layer[key] = Setting{Value: value, Origin: origin}
+	}
+	return layer, nil
+}
+
+// FromEnv builds a layer from LLM_RADAR_* variables, e.g. LLM_RADAR_TIMEOUT
+// or LLM_RADAR_MAX_OUTPUT_KB. List values are comma-separated. Variables
+// that don't match a config key are ignored.
+func FromEnv(environ []string) Layer {
+	layer := Layer{}
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimPrefix(name, EnvPrefix))
+		f, ok := lookupField(key)
+		if !ok {
+			continue
+		}
+
+		var v any = value
+		if f.list {
+			v = asList(value)
+		}
+		layer[key] = Setting{Value: v, Origin: Origin{Source: SourceEnv, Detail: name}}
+	}
+	return layer
+}
+
+// ============================================================================
+// RESOLUTION
+// ============================================================================
+
+// Resolve applies layers over the defaults, from lowest to highest
+// precedence: user file, project file, environment, flags.
+func Resolve(layers ...Layer) (Resolved, error) {
+	res := Resolved{
+		Config:  Defaults(),
+		Origins: make(map[string]Origin, len(fields)),
+	}
+	for _, f := range fields {
+		res.Origins[f.key] = Origin{Source: SourceDefault}
+	}
+
+	for _, layer := range layers {
+		for _, key := range sortedKeys(layer) {
+			setting := layer[key]
+			f, ok := lookupField(key)
+			if !ok {
+				return Resolved{}, fmt.Errorf("%s: chave desconhecida %q", setting.Origin, key)
+			}
+			if err := f.set(&res.Config, setting.Value); err != nil {
+				return Resolved{}, fmt.Errorf("%s: valor inválido para %s: %w", setting.Origin, key, err)
+			}
+			res.Origins[key] = setting.Origin
+		}
+	}
+
+	return res, nil
+}
+
+// Show prints the effective configuration with the origin of each value.
+func (r Resolved) Show(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHAVE\tVALOR\tORIGEM")
+	for _, f := range fields {
+		value := f.get(r.Config)
+		if value == "" {
+			value = `""`
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.key, value, r.Origins[f.key])
+	}
+	return tw.Flush()
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// normalize converts a decoded YAML value to a string or []string.
+func normalize(v any, list bool) (any, error) {
+	switch val := v.(type) {
+	case []any:
+		if !list {
+			return nil, fmt.Errorf("lista não permitida")
+		}
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if _, nested := item.([]any); nested {
+				return nil, fmt.Errorf("listas aninhadas não são suportadas")
+			}
+			out = append(out, fmt.Sprint(item))
+		}
+		return out, nil
+	case map[string]any:
+		return nil, fmt.Errorf("objetos não são suportados")
+	case nil:
+		if list {
+			return []string{}, nil
+		}
+		return "", nil
+	default:
+		if list {
+			return asList(fmt.Sprint(val)), nil
+		}
+		return fmt.Sprint(val), nil
+	}
+}
+
+func asString(v any) string {
+	if list, ok := v.([]string); ok {
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+func asList(v any) []string {
+	if list, ok := v.([]string); ok {
+		return list
+	}
+	var out []string
+	for _, item := range strings.Split(fmt.Sprint(v), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func formatList(list []string) string {
+	if len(list) == 0 {
+		return "[]"
+	}
+	return "[" + strings.Join(list, ", ") + "]"
+}
+
+func parseNonNegative(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("deve ser >= 0")
+	}
+	return n, nil
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		homeDir, _ := os.UserHomeDir()
+		return filepath.Join(homeDir, rest)
+	}
+	return path
+}
+
+func sortedKeys(layer Layer) []string {
+	keys := make([]string, 0, len(layer))
+	for k := range layer {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
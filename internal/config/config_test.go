@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveDefaults(t *testing.T) {
+	res, err := Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Config.Timeout != 20*time.Second {
+		t.Errorf("Expected default timeout 20s, got %s", res.Config.Timeout)
+	}
+	if res.Origins["prompt"].Source != SourceDefault {
+		t.Errorf("Expected default origin, got %v", res.Origins["prompt"])
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, `
+prompt: "Write only: 2, 3, 5"
+timeout: 45s
+retries: 3
+cache: true
+providers: [groq, cerebras]
+exclude:
+  - "*preview*"
+  - "re:(?i)vision"
+`)
+
+	layer, err := LoadFile(path, SourceUser)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	res, err := Resolve(layer)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	cfg := res.Config
+	if cfg.Prompt != "Write only: 2, 3, 5" || cfg.Timeout != 45*time.Second || cfg.Retries != 3 || !cfg.UseCache {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Providers, []string{"groq", "cerebras"}) {
+		t.Errorf("Providers mismatch: %v", cfg.Providers)
+	}
+	if !reflect.DeepEqual(cfg.Exclude, []string{"*preview*", "re:(?i)vision"}) {
+		t.Errorf("Exclude mismatch: %v", cfg.Exclude)
+	}
+	if res.Origins["timeout"] != (Origin{Source: SourceUser, Detail: path}) {
+		t.Errorf("Unexpected origin: %v", res.Origins["timeout"])
+	}
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	layer, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"), SourceUser)
+	if err != nil || len(layer) != 0 {
+		t.Errorf("Missing file should yield an empty layer, got %v, %v", layer, err)
+	}
+}
+
+func TestLoadFileUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "timeuot: 10s\n")
+
+	if _, err := LoadFile(path, SourceUser); err == nil {
+		t.Error("Expected error for unknown key")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	layer := FromEnv([]string{
+		"LLM_RADAR_MAX_OUTPUT_KB=32",
+		"LLM_RADAR_INCLUDE=groq/*, cerebras/*",
+		"LLM_RADAR_UNRELATED=x",
+		"PATH=/usr/bin",
+	})
+
+	res, err := Resolve(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Config.MaxOutputKB != 32 {
+		t.Errorf("Expected MaxOutputKB 32, got %d", res.Config.MaxOutputKB)
+	}
+	if !reflect.DeepEqual(res.Config.Include, []string{"groq/*", "cerebras/*"}) {
+		t.Errorf("Include mismatch: %v", res.Config.Include)
+	}
+	if res.Origins["max_output_kb"].Detail != "LLM_RADAR_MAX_OUTPUT_KB" {
+		t.Errorf("Unexpected origin: %v", res.Origins["max_output_kb"])
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "user.yaml")
+	projectPath := filepath.Join(dir, ProjectFileName)
+	writeFile(t, userPath, "timeout: 10s\nretries: 5\nconcurrency: 2\nprompt: user\n")
+	writeFile(t, projectPath, "timeout: 30s\nretries: 4\nconcurrency: 3\n")
+
+	user, _ := LoadFile(userPath, SourceUser)
+	project, _ := LoadFile(projectPath, SourceProject)
+	env := FromEnv([]string{"LLM_RADAR_TIMEOUT=40s", "LLM_RADAR_RETRIES=2"})
+	flags := Layer{"timeout": {Value: "50s", Origin: Origin{Source: SourceFlag, Detail: "-t"}}}
+
+	res, err := Resolve(user, project, env, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		source string
+	}{
+		{"timeout", SourceFlag},
+		{"retries", SourceEnv},
+		{"concurrency", SourceProject},
+		{"prompt", SourceUser},
+		{"max_output_kb", SourceDefault},
+	}
+	for _, tt := range tests {
+		if got := res.Origins[tt.key].Source; got != tt.source {
+			t.Errorf("%s: expected source %s, got %s", tt.key, tt.source, got)
+		}
+	}
+	if res.Config.Timeout != 50*time.Second || res.Config.Retries != 2 || res.Config.Concurrency != 3 {
+		t.Errorf("Unexpected merged config: %+v", res.Config)
+	}
+}
+
+func TestResolveInvalidValue(t *testing.T) {
+	env := FromEnv([]string{"LLM_RADAR_TIMEOUT=soon"})
+	if _, err := Resolve(env); err == nil {
+		t.Error("Expected error for invalid duration")
+	}
+}
+
+func TestResolveRejectsNonPositiveDurations(t *testing.T) {
+	for _, kv := range []string{
+		"LLM_RADAR_TIMEOUT=0",
+		"LLM_RADAR_TIMEOUT=-5s",
+		"LLM_RADAR_SAMPLE_INTERVAL=-1s",
+	} {
+		_, err := Resolve(FromEnv([]string{kv}))
+		if name, _, _ := strings.Cut(kv, "="); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: expected an error naming the variable, got %v", kv, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "timeout: 0\n")
+	layer, err := LoadFile(path, SourceUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resolve(layer); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected an error naming the file, got %v", err)
+	}
+
+	if _, err := Resolve(FromEnv([]string{"LLM_RADAR_SAMPLE_INTERVAL=0"})); err != nil {
+		t.Errorf("A zero sample interval should be valid: %v", err)
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, ProjectFileName), "retries: 2\n")
+
+	if got := FindProjectConfig(nested); got != filepath.Join(root, ProjectFileName) {
+		t.Errorf("Expected project file in ancestor, got %q", got)
+	}
+}
+
+func TestShow(t *testing.T) {
+	res, _ := Resolve(FromEnv([]string{"LLM_RADAR_RETRIES=3"}))
+
+	var buf bytes.Buffer
+	if err := res.Show(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, key := range Keys() {
+		if !strings.Contains(out, key) {
+			t.Errorf("Show output missing key %q", key)
+		}
+	}
+	if !strings.Contains(out, "env (LLM_RADAR_RETRIES)") {
+		t.Error("Show output should include the origin of each value")
+	}
+}
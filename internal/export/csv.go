@@ -0,0 +1,41 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{
+	"model", "provider", "category", "reason",
+	"duration_ms", "exit_code", "probe", "wait_ms", "attempts", "timestamp",
+}
+
+func writeCSV(w io.Writer, doc Document) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range doc.Results {
+		record := []string{
+			r.Model,
+			r.Provider,
+			r.Category,
+			r.Reason,
+			strconv.FormatInt(r.DurationMs, 10),
+			strconv.Itoa(r.ExitCode),
+			r.Probe,
+			strconv.FormatInt(r.WaitMs, 10),
+			strconv.Itoa(len(r.Attempts)),
+			r.Timestamp,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
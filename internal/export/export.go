@@ -0,0 +1,162 @@
+// Package export renders run results as JSON, CSV, Markdown or HTML files.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"llm-radar/internal/models"
+)
+
+// Supported export formats.
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+)
+
+// ============================================================================
+// DOCUMENT AND REGISTRY
+// ============================================================================
+
+// Document is the data shared by every exporter.
+type Document struct {
+	App       string               `json:"app"`
+	Version   string               `json:"version"`
+	Timestamp string               `json:"timestamp"`
+	Summary   models.RunSummary    `json:"summary"`
+	Results   []models.ModelResult `json:"results"`
+}
+
+// Exporter writes a Document in a specific format.
+type Exporter interface {
+	Export(w io.Writer, doc Document) error
+}
+
+// ExporterFunc adapts a function to the Exporter interface.
+type ExporterFunc func(w io.Writer, doc Document) error
+
+// Export calls f(w, doc).
+func (f ExporterFunc) Export(w io.Writer, doc Document) error {
+	return f(w, doc)
+}
+
+var exporters = map[string]Exporter{
+	FormatJSON:     ExporterFunc(writeJSON),
+	FormatCSV:      ExporterFunc(writeCSV),
+	FormatMarkdown: ExporterFunc(writeMarkdown),
+	FormatHTML:     ExporterFunc(writeHTML),
+}
+
+// NewDocument builds a Document from the results of a run.
+func NewDocument(app, version string, results []models.ModelResult) Document {
+	return Document{
+		App:       app,
+		Version:   version,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Summary:   models.Summarize(results),
+		Results:   results,
+	}
+}
+
+// Formats returns the supported format names in a stable order.
+func Formats() []string {
+	formats := make([]string, 0, len(exporters))
+	for f := range exporters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Get returns the exporter registered for a format.
+func Get(format string) (Exporter, error) {
+	e, ok := exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("formato de exportação desconhecido: %q", format)
+	}
+	return e, nil
+}
+
+// ============================================================================
+// FILE OUTPUT
+// ============================================================================
+
+// DefaultDir returns the directory where reports are saved by default.
+func DefaultDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "opencode", "results")
+}
+
+// ResolvePath returns path unchanged, or a timestamped file name in
+// DefaultDir when path is empty.
+func ResolvePath(path, format string, now time.Time) string {
+	if path != "" {
+		return path
+	}
+	filename := fmt.Sprintf("llm-radar-%s.%s", now.Format("20060102-150405"), format)
+	return filepath.Join(DefaultDir(), filename)
+}
+
+// WriteFile exports doc to path, creating parent directories.
+func WriteFile(path, format string, doc Document) error {
+	e, err := Get(format)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := e.Export(f, doc); err != nil {
+		f.Close()
+		return fmt.Errorf("erro ao exportar %s: %w", format, err)
+	}
+	return f.Close()
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+func writeJSON(w io.Writer, doc Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// groupByProvider returns results grouped by provider, both sorted by name.
+func groupByProvider(results []models.ModelResult) ([]string, map[string][]models.ModelResult) {
+	groups := make(map[string][]models.ModelResult)
+	for _, r := range results {
+		groups[r.Provider] = append(groups[r.Provider], r)
+	}
+
+	providers := make([]string, 0, len(groups))
+	for p, rs := range groups {
+		providers = append(providers, p)
+		sort.Slice(rs, func(i, j int) bool { return rs[i].Model < rs[j].Model })
+	}
+	sort.Strings(providers)
+
+	return providers, groups
+}
+
+func percent(n, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return n * 100 / total
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"llm-radar/internal/models"
+)
+
+func sampleDocument() Document {
+	return NewDocument("LLM Radar", "test", []models.ModelResult{
+		{
+			Model: "opencode/big-pickle", Provider: "opencode",
+			Category: models.CategoryFree, Icon: models.CategoryIcons[models.CategoryFree],
+			Reason: "Zen - Big Pickle", Duration: "1.2s", DurationMs: 1200,
+		},
+		{
+			Model: "anthropic/claude", Provider: "anthropic",
+			Category: models.CategoryAuthFailed, Icon: models.CategoryIcons[models.CategoryAuthFailed],
+			Reason: "API key | inválida", Duration: "300ms", DurationMs: 300, ExitCode: 1,
+			Attempts: []models.Attempt{
+				{Category: models.CategoryError, ExitCode: 1, DurationMs: 100, BackoffMs: 500},
+				{Category: models.CategoryAuthFailed, ExitCode: 1, DurationMs: 300},
+			},
+		},
+	})
+}
+
+func exportString(t *testing.T, format string) string {
+	t.Helper()
+
+	e, err := Get(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := e.Export(&buf, sampleDocument()); err != nil {
+		t.Fatalf("Export %s failed: %v", format, err)
+	}
+	return buf.String()
+}
+
+func TestGetUnknownFormat(t *testing.T) {
+	if _, err := Get("pdf"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	formats := Formats()
+	if len(formats) != 4 {
+		t.Errorf("Expected 4 formats, got %v", formats)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	var doc Document
+	if err := json.Unmarshal([]byte(exportString(t, FormatJSON)), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if doc.Summary.Total != 2 || len(doc.Results) != 2 {
+		t.Errorf("Unexpected document: %+v", doc.Summary)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	records, err := csv.NewReader(strings.NewReader(exportString(t, FormatCSV))).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header + 2 rows, got %d", len(records))
+	}
+	if records[2][2] != models.CategoryAuthFailed {
+		t.Errorf("Category column mismatch: %v", records[2])
+	}
+	if records[0][8] != "attempts" || records[2][8] != "2" {
+		t.Errorf("Attempts column mismatch: %v / %v", records[0], records[2])
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	out := exportString(t, FormatMarkdown)
+
+	for _, want := range []string{
+		"# Relatório de Disponibilidade de Modelos",
+		"> Total de modelos: **2**",
+		"### anthropic",
+		"### opencode",
+		"| opencode/big-pickle | 🆓 FREE |",
+		`API key \| inválida (2 tentativas)`,
+		"## Resumo Estatístico",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown missing %q", want)
+		}
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	out := exportString(t, FormatHTML)
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`class="cat success"`,
+		`class="cat danger"`,
+		"table.sortable",
+		"opencode/big-pickle",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<script src=") || strings.Contains(out, `<link rel="stylesheet"`) {
+		t.Error("HTML report must be self-contained")
+	}
+}
+
+func TestWriteFileAndResolvePath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "report.md")
+
+	if got := ResolvePath(path, FormatMarkdown, time.Now()); got != path {
+		t.Errorf("Explicit path should be kept, got %s", got)
+	}
+	if err := WriteFile(path, FormatMarkdown, sampleDocument()); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Report not written: %v", err)
+	}
+
+	ts := time.Date(2026, 2, 6, 10, 30, 0, 0, time.UTC)
+	if got := ResolvePath("", FormatHTML, ts); filepath.Base(got) != "llm-radar-20260206-103000.html" {
+		t.Errorf("Unexpected default file name: %s", got)
+	}
+}
@@ -0,0 +1,157 @@
+package export
+
+import (
+	"html/template"
+	"io"
+
+	"llm-radar/internal/models"
+)
+
+// htmlCategory is a row of the summary table.
+type htmlCategory struct {
+	Name        string
+	Icon        string
+	Description string
+	Severity    string
+	Count       int
+	Percent     int
+}
+
+// htmlData is the template input.
+type htmlData struct {
+	Doc        Document
+	Percent    int
+	Providers  int
+	Categories []htmlCategory
+}
+
+var htmlFuncs = template.FuncMap{
+	"severity": models.CategorySeverity,
+}
+
+// The palette mirrors the TUI's adaptive colors so categories look the
+// same in the terminal and in the report.
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Doc.App}} - Relatório de Disponibilidade</title>
+<style>
+  :root {
+    --success: #43BF6D; --warning: #F2B824; --danger: #F24A4A;
+    --info: #00A1E4; --highlight: #874BFD; --subtle: #D9DCCF;
+    --bg: #ffffff; --fg: #1f1f1f;
+  }
+  @media (prefers-color-scheme: dark) {
+    :root {
+      --success: #73F59F; --highlight: #7D56F4; --subtle: #383838;
+      --bg: #161616; --fg: #e6e6e6;
+    }
+  }
+  body { font-family: system-ui, sans-serif; background: var(--bg); color: var(--fg); margin: 2rem; }
+  h1 { color: var(--highlight); }
+  .meta { color: gray; margin-bottom: 1.5rem; }
+  table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+  th, td { padding: .4rem .6rem; border-bottom: 1px solid var(--subtle); text-align: left; }
+  th { cursor: pointer; user-select: none; white-space: nowrap; }
+  th.asc::after { content: " ▲"; }
+  th.desc::after { content: " ▼"; }
+  td.num { text-align: right; font-variant-numeric: tabular-nums; }
+  .cat { font-weight: bold; white-space: nowrap; }
+  .success { color: var(--success); }
+  .warning { color: var(--warning); }
+  .danger { color: var(--danger); }
+</style>
+</head>
+<body>
+<h1>🧪 {{.Doc.App}} v{{.Doc.Version}}</h1>
+<div class="meta">
+  Gerado em {{.Doc.Timestamp}} ·
+  {{.Doc.Summary.Total}} modelos · {{.Providers}} provedores ·
+  <span class="success">{{.Doc.Summary.Usable}} utilizáveis ({{.Percent}}%)</span>
+</div>
+
+<h2>Resumo</h2>
+<table class="sortable">
+<thead><tr><th>Categoria</th><th>Descrição</th><th data-type="number">Modelos</th><th data-type="number">%</th></tr></thead>
+<tbody>
+{{- range .Categories}}
+<tr>
+  <td class="cat {{.Severity}}">{{.Icon}} {{.Name}}</td>
+  <td>{{.Description}}</td>
+  <td class="num">{{.Count}}</td>
+  <td class="num">{{.Percent}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+
+<h2>Modelos</h2>
+<table class="sortable">
+<thead><tr><th>Modelo</th><th>Provedor</th><th>Categoria</th><th>Motivo</th><th data-type="number">Duração (ms)</th><th data-type="number">Exit</th><th data-type="number">Tentativas</th></tr></thead>
+<tbody>
+{{- range .Doc.Results}}
+<tr>
+  <td>{{.Model}}</td>
+  <td>{{.Provider}}</td>
+  <td class="cat {{severity .Category}}">{{.Icon}} {{.Category}}</td>
+  <td>{{.Reason}}</td>
+  <td class="num">{{.DurationMs}}</td>
+  <td class="num">{{.ExitCode}}</td>
+  <td class="num">{{len .Attempts}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  var headers = table.querySelectorAll("th");
+  headers.forEach(function (th, col) {
+    th.addEventListener("click", function () {
+      var asc = !th.classList.contains("asc");
+      headers.forEach(function (h) { h.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      var numeric = th.dataset.type === "number";
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[col].textContent.trim(), y = b.cells[col].textContent.trim();
+        var cmp = numeric ? (parseFloat(x) || 0) - (parseFloat(y) || 0) : x.localeCompare(y);
+        return asc ? cmp : -cmp;
+      });
+      rows.forEach(function (r) { body.appendChild(r); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, doc Document) error {
+	providers, _ := groupByProvider(doc.Results)
+
+	data := htmlData{
+		Doc:       doc,
+		Percent:   percent(doc.Summary.Usable, doc.Summary.Total),
+		Providers: len(providers),
+	}
+	for _, cat := range models.AllCategories() {
+		count := doc.Summary.Categories[cat]
+		if count == 0 {
+			continue
+		}
+		data.Categories = append(data.Categories, htmlCategory{
+			Name:        cat,
+			Icon:        models.CategoryIcons[cat],
+			Description: models.CategoryDescriptions[cat],
+			Severity:    models.CategorySeverity(cat),
+			Count:       count,
+			Percent:     percent(count, doc.Summary.Total),
+		})
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"llm-radar/internal/models"
+)
+
+const barWidth = 20
+
+// writeMarkdown follows the layout of models/availability-report.md.
+func writeMarkdown(w io.Writer, doc Document) error {
+	bw := bufio.NewWriter(w)
+	providers, groups := groupByProvider(doc.Results)
+	summary := doc.Summary
+
+	fmt.Fprintln(bw, "# Relatório de Disponibilidade de Modelos")
+	fmt.Fprintln(bw)
+	fmt.Fprintf(bw, "> Gerado em: %s  \n", doc.Timestamp)
+	fmt.Fprintf(bw, "> Total de modelos: **%d**  \n", summary.Total)
+	fmt.Fprintf(bw, "> Provedores: **%d**  \n", len(providers))
+	fmt.Fprintf(bw, "> Utilizáveis: **%d** (%d%%)\n", summary.Usable, percent(summary.Usable, summary.Total))
+	fmt.Fprintln(bw)
+
+	fmt.Fprintln(bw, "## Legenda de Classificação")
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "| Ícone | Categoria | Descrição |")
+	fmt.Fprintln(bw, "|:-----:|-----------|-----------|")
+	for _, cat := range models.AllCategories() {
+		fmt.Fprintf(bw, "| %s | %s | %s |\n",
+			models.CategoryIcons[cat], cat, models.CategoryDescriptions[cat])
+	}
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "---")
+	fmt.Fprintln(bw)
+
+	fmt.Fprintln(bw, "## Modelos por Provedor")
+	fmt.Fprintln(bw)
+	for _, provider := range providers {
+		fmt.Fprintf(bw, "### %s\n\n", provider)
+		fmt.Fprintln(bw, "| Modelo | Classificação | Motivo | Duração |")
+		fmt.Fprintln(bw, "|--------|:-------------:|--------|--------:|")
+		for _, r := range groups[provider] {
+			fmt.Fprintf(bw, "| %s | %s %s | %s | %s |\n",
+				mdCell(r.Model), r.Icon, r.Category, mdCell(reasonWithAttempts(r)), r.Duration)
+		}
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, "---")
+		fmt.Fprintln(bw)
+	}
+
+	fmt.Fprintln(bw, "## Resumo Estatístico")
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "```")
+	for _, cat := range models.AllCategories() {
+		count := summary.Categories[cat]
+		if count == 0 {
+			continue
+		}
+		filled := 0
+		if summary.Total > 0 {
+			filled = count * barWidth / summary.Total
+		}
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+		fmt.Fprintf(bw, "%s %-14s │ %4d modelos │ %s %3d%%\n",
+			models.CategoryIcons[cat], cat, count, bar, percent(count, summary.Total))
+	}
+	fmt.Fprintln(bw, "```")
+
+	return bw.Flush()
+}
+
+// mdCell escapes text so it fits in a single Markdown table cell.
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r", " ")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// reasonWithAttempts notes retried results in the reason column, keeping
+// the table layout of the reference report.
+func reasonWithAttempts(r models.ModelResult) string {
+	if n := len(r.Attempts); n > 1 {
+		return fmt.Sprintf("%s (%d tentativas)", r.Reason, n)
+	}
+	return r.Reason
+}
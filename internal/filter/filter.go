@@ -0,0 +1,154 @@
+// Package filter selects which discovered models are probed.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RegexPrefix marks a pattern as a regular expression instead of a glob.
+const RegexPrefix = "re:"
+
+// Filter keeps models that belong to one of the providers (if any are set),
+// match at least one include pattern (if any are set) and match no exclude
+// pattern.
+type Filter struct {
+	providers map[string]bool
+	include   []*regexp.Regexp
+	exclude   []*regexp.Regexp
+}
+
+// New compiles the provider list and include/exclude patterns.
+// Patterns are globs where * matches any sequence (including "/") and ?
+// matches one character; patterns prefixed with "re:" are regular
+// expressions.
+func New(providers, include, exclude []string) (Filter, error) {
+	f := Filter{providers: make(map[string]bool)}
+
+	for _, p := range providers {
+		if p = strings.TrimSpace(p); p != "" {
+			f.providers[p] = true
+		}
+	}
+
+	var err error
+	if f.include, err = compileAll(include); err != nil {
+		return Filter{}, err
+	}
+	if f.exclude, err = compileAll(exclude); err != nil {
+		return Filter{}, err
+	}
+	return f, nil
+}
+
+// IsEmpty reports whether the filter keeps every model.
+func (f Filter) IsEmpty() bool {
+	return len(f.providers) == 0 && len(f.include) == 0 && len(f.exclude) == 0
+}
+
+// Match reports whether a model passes the filter.
+func (f Filter) Match(model string) bool {
+	if len(f.providers) > 0 {
+		provider, _, _ := strings.Cut(model, "/")
+		if !f.providers[provider] {
+			return false
+		}
+	}
+
+	if len(f.include) > 0 && !matchAny(f.include, model) {
+		return false
+	}
+
+	return !matchAny(f.exclude, model)
+}
+
+// Apply returns the models that pass the filter, preserving order.
+func (f Filter) Apply(models []string) []string {
+	if f.IsEmpty() {
+		return models
+	}
+
+	kept := make([]string, 0, len(models))
+	for _, m := range models {
+		if f.Match(m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// ============================================================================
+// PATTERN COMPILATION
+// ============================================================================
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Compile turns a glob or "re:" pattern into a regular expression. Globs are
+// anchored to the whole model name; "re:" patterns match anywhere in it.
+func Compile(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, RegexPrefix); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("regex inválida %q: %w", expr, err)
+		}
+		return re, nil
+	}
+
+	re, err := regexp.Compile(globToRegex(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("glob inválido %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+func globToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+
+	inClass := false
+	for _, r := range glob {
+		switch {
+		case inClass:
+			if r == ']' {
+				inClass = false
+			}
+			b.WriteRune(r)
+		case r == '*':
+			b.WriteString(".*")
+		case r == '?':
+			b.WriteString(".")
+		case r == '[':
+			inClass = true
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+	return b.String()
+}
+
+func matchAny(patterns []*regexp.Regexp, model string) bool {
+	for _, re := range patterns {
+		if re.MatchString(model) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+var sampleModels = []string{
+	"anthropic/claude-sonnet",
+	"groq/llama-3.3-70b",
+	"openrouter/deepseek/deepseek-r1:free",
+	"openrouter/qwen/qwen3-coder:free",
+	"openrouter/openai/gpt-5",
+	"opencode/big-pickle",
+}
+
+func TestEmptyFilterKeepsAll(t *testing.T) {
+	f, err := New(nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Apply(sampleModels); len(got) != len(sampleModels) {
+		t.Errorf("Expected all models, got %v", got)
+	}
+}
+
+func TestProviderFilter(t *testing.T) {
+	f, _ := New([]string{"groq", "opencode"}, nil, nil)
+
+	want := []string{"groq/llama-3.3-70b", "opencode/big-pickle"}
+	if got := f.Apply(sampleModels); !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+}
+
+func TestIncludeGlob(t *testing.T) {
+	f, _ := New(nil, []string{"openrouter/*:free"}, nil)
+
+	want := []string{
+		"openrouter/deepseek/deepseek-r1:free",
+		"openrouter/qwen/qwen3-coder:free",
+	}
+	if got := f.Apply(sampleModels); !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+}
+
+func TestExcludeRegex(t *testing.T) {
+	f, _ := New([]string{"openrouter"}, nil, []string{"re:(deepseek|gpt)"})
+
+	want := []string{"openrouter/qwen/qwen3-coder:free"}
+	if got := f.Apply(sampleModels); !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+}
+
+func TestGlobIsAnchored(t *testing.T) {
+	re, err := Compile("groq/llama?3*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"groq/llama-3.3-70b":   true,
+		"x/groq/llama-3.3-70b": false,
+		"groq/llama3":          false,
+		"groq/llama.3":         true,
+	}
+	for model, want := range tests {
+		if got := re.MatchString(model); got != want {
+			t.Errorf("Match(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestInvalidRegex(t *testing.T) {
+	if _, err := New(nil, []string{"re:[invalid"}, nil); err == nil {
+		t.Error("Expected error for invalid regex")
+	}
+}
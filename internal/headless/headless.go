@@ -0,0 +1,227 @@
+// Package headless runs the model probes without the Bubble Tea UI,
+// streaming machine-readable results for CI, cron and shell pipelines.
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-radar/internal/cache"
+	"llm-radar/internal/checkpoint"
+	"llm-radar/internal/export"
+	"llm-radar/internal/history"
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
+	"llm-radar/internal/worker"
+)
+
+// Output formats supported by Run.
+const (
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+)
+
+// Options controls a headless run.
+type Options struct {
+	Format      string
+	AppName     string
+	Version     string
+	CacheExpiry time.Duration
+	// Models, when set, replaces discovery with a fixed model list.
+	Models []string
+	// CheckpointDir, when set, keeps a run file there until the run
+	// completes.
+	CheckpointDir string
+	// Resume continues an interrupted run, probing only its remaining
+	// models. Requires CheckpointDir.
+	Resume *checkpoint.Run
+}
+
+// Report is the final document of a headless run.
+type Report struct {
+	export.Document
+	RunID       string       `json:"run_id,omitempty"`
+	Interrupted bool         `json:"interrupted,omitempty"` // stopped by a signal; the run file is kept
+	CacheStats  *cache.Stats `json:"cache_stats,omitempty"`
+}
+
+// ValidFormat reports whether format is a supported output format.
+func ValidFormat(format string) bool {
+	return format == FormatNDJSON || format == FormatJSON
+}
+
+// Run discovers (or takes from opts.Models) and probes all models, writing
+// results to w.
+// In NDJSON mode each result is written as one line as soon as it finishes;
+// in JSON mode a single Report is written when the run completes.
+func Run(w io.Writer, runCfg models.RunConfig, compiledKB kb.Compiled, opts Options) (Report, error) {
+	if !ValidFormat(opts.Format) {
+		return Report{}, fmt.Errorf("formato desconhecido: %q", opts.Format)
+	}
+
+	var modelList []string
+	var prior []models.ModelResult
+	if opts.Resume != nil {
+		// The checkpointed queue was already filtered and ordered.
+		modelList = opts.Resume.Remaining()
+		prior = opts.Resume.Results
+	} else {
+		modelList = opts.Models
+		if modelList == nil {
+			var err error
+			warn := func(err error) { fmt.Fprintf(os.Stderr, "⚠️  %v\n", err) }
+			if modelList, err = worker.DiscoverAll(compiledKB, warn); err != nil {
+				return Report{}, err
+			}
+		}
+		var err error
+		if modelList, err = worker.PrepareQueue(modelList, runCfg, compiledKB); err != nil {
+			return Report{}, err
+		}
+	}
+
+	cp, err := openCheckpoint(modelList, runCfg, compiledKB, opts)
+	if err != nil {
+		return Report{}, err
+	}
+
+	resultCache := cache.New(runCfg.CachePath, opts.CacheExpiry)
+	resultCache.SetCategoryTTLs(compiledKB.CacheTTLs)
+	if runCfg.UseCache {
+		if err := resultCache.Load(); err != nil {
+			return Report{}, err
+		}
+	}
+
+	// An interrupt or SIGTERM cancels the running probes; the run still
+	// ends normally so partial results are reported and backends cleaned up.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results, err := probe(ctx, w, modelList, prior, runCfg, compiledKB, resultCache, cp, opts.Format)
+	if err != nil {
+		if cp != nil {
+			cp.Close()
+		}
+		return Report{}, err
+	}
+
+	report := Report{Document: export.NewDocument(opts.AppName, opts.Version, results)}
+	report.Interrupted = ctx.Err() != nil
+	if cp != nil {
+		report.RunID = cp.ID()
+		finish := cp.Finish
+		if report.Interrupted {
+			// Keep the run file for --resume.
+			finish = cp.Close
+		}
+		if err := finish(); err != nil {
+			return report, err
+		}
+	}
+
+	if runCfg.HistoryPath != "" {
+		// The history only orders later runs; losing it must not fail this one.
+		if err := history.Update(runCfg.HistoryPath, results); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Histórico não atualizado: %v\n", err)
+		}
+	}
+
+	if runCfg.UseCache {
+		if err := resultCache.SaveResults(results, worker.CacheKeyFunc(runCfg, compiledKB)); err != nil {
+			return report, err
+		}
+		stats := resultCache.Stats()
+		report.CacheStats = &stats
+	}
+
+	if opts.Format == FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return report, fmt.Errorf("erro ao escrever relatório: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// openCheckpoint starts the run file, or reopens it when resuming. It
+// returns nil when checkpoints are disabled.
+func openCheckpoint(modelList []string, runCfg models.RunConfig, compiledKB kb.Compiled, opts Options) (*checkpoint.Writer, error) {
+	switch {
+	case opts.CheckpointDir == "":
+		return nil, nil
+	case opts.Resume != nil:
+		return checkpoint.Reopen(opts.CheckpointDir, *opts.Resume)
+	}
+
+	now := time.Now()
+	return checkpoint.Create(opts.CheckpointDir, checkpoint.Header{
+		RunID:   checkpoint.NewID(now),
+		Started: now,
+		Prompt:  runCfg.Prompt,
+		Probe:   runCfg.Probe,
+		KBHash:  compiledKB.Fingerprint(),
+		Models:  modelList,
+	})
+}
+
+// probe runs the workers and collects their results in completion order,
+// after the prior results of a resumed run. New results are appended to cp
+// when it is set. Cancelling ctx aborts the running probes.
+func probe(
+	ctx context.Context,
+	w io.Writer,
+	modelList []string,
+	prior []models.ModelResult,
+	runCfg models.RunConfig,
+	compiledKB kb.Compiled,
+	resultCache *cache.ResultCache,
+	cp *checkpoint.Writer,
+	format string,
+) ([]models.ModelResult, error) {
+	msgChan := make(chan tea.Msg, 100)
+	var processed int32
+
+	go worker.StartWorkers(ctx, modelList, runCfg, compiledKB, resultCache, msgChan, &processed)
+
+	enc := json.NewEncoder(w)
+	results := make([]models.ModelResult, 0, len(prior)+len(modelList))
+	var writeErr error
+
+	emit := func(res models.ModelResult) {
+		results = append(results, res)
+		if format == FormatNDJSON && writeErr == nil {
+			if err := enc.Encode(res); err != nil {
+				writeErr = fmt.Errorf("erro ao escrever resultado: %w", err)
+			}
+		}
+	}
+	for _, res := range prior {
+		emit(res)
+	}
+
+	// Keep draining after a write error so the workers can finish.
+	for msg := range msgChan {
+		res, ok := msg.(models.ModelResult)
+		if !ok {
+			continue
+		}
+		emit(res)
+
+		if cp != nil && writeErr == nil {
+			writeErr = cp.Append(res)
+		}
+	}
+
+	return results, writeErr
+}
@@ -0,0 +1,291 @@
+package headless
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"llm-radar/internal/checkpoint"
+	"llm-radar/internal/history"
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
+)
+
+// installFakeOpencode puts a scripted opencode CLI first on PATH.
+func installFakeOpencode(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+if [ "$1" = "models" ]; then
+  echo "test/good"
+  echo "test/auth"
+  exit 0
+fi
+if [ "$3" = "test/auth" ]; then
+  echo "Error: unauthorized"
+  exit 1
+fi
+case "$4" in
+  *OK*) echo "OK"; exit 0 ;;
+esac
+echo "2, 3, 5"
+`
+	if err := os.WriteFile(filepath.Join(dir, "opencode"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func testRunConfig(t *testing.T) models.RunConfig {
+	return models.RunConfig{
+		Prompt:      "Escreva apenas: 2, 3, 5",
+		Timeout:     5 * time.Second,
+		Concurrency: 2,
+		MaxOutputKB: 64,
+		CachePath:   filepath.Join(t.TempDir(), "results.json"),
+	}
+}
+
+func testKB(t *testing.T) kb.Compiled {
+	compiled, err := kb.Compile(kb.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
+
+func TestRunNDJSON(t *testing.T) {
+	installFakeOpencode(t)
+
+	var buf bytes.Buffer
+	report, err := Run(&buf, testRunConfig(t), testKB(t), Options{Format: FormatNDJSON})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	categories := make(map[string]string)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var res models.ModelResult
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			t.Fatalf("Line is not a ModelResult: %q: %v", scanner.Text(), err)
+		}
+		categories[res.Model] = res.Category
+	}
+
+	if len(categories) != 2 {
+		t.Fatalf("Expected 2 NDJSON lines, got %d", len(categories))
+	}
+	if categories["test/good"] != models.CategoryAvailable {
+		t.Errorf("test/good: got %s", categories["test/good"])
+	}
+	if categories["test/auth"] != models.CategoryAuthFailed {
+		t.Errorf("test/auth: got %s", categories["test/auth"])
+	}
+	if report.Summary.Usable != 1 {
+		t.Errorf("Expected 1 usable model, got %d", report.Summary.Usable)
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	installFakeOpencode(t)
+
+	var buf bytes.Buffer
+	_, err := Run(&buf, testRunConfig(t), testKB(t), Options{
+		Format:  FormatJSON,
+		AppName: "LLM Radar",
+		Version: "test",
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Output is not a single JSON document: %v", err)
+	}
+	if report.Summary.Total != 2 || len(report.Results) != 2 {
+		t.Errorf("Expected 2 results, got summary=%d results=%d", report.Summary.Total, len(report.Results))
+	}
+	if report.Summary.Categories[models.CategoryAuthFailed] != 1 {
+		t.Errorf("Expected 1 AUTH_FAILED, got %v", report.Summary.Categories)
+	}
+}
+
+func TestRunWithModelList(t *testing.T) {
+	installFakeOpencode(t)
+
+	var buf bytes.Buffer
+	report, err := Run(&buf, testRunConfig(t), testKB(t), Options{
+		Format: FormatJSON,
+		Models: []string{"curated/model"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(report.Results) != 1 || report.Results[0].Model != "curated/model" {
+		t.Errorf("Expected only the curated model, got %+v", report.Results)
+	}
+}
+
+func TestRunRejectsUnknownFormat(t *testing.T) {
+	if _, err := Run(&bytes.Buffer{}, testRunConfig(t), testKB(t), Options{Format: "xml"}); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestRunWithProbe(t *testing.T) {
+	installFakeOpencode(t)
+
+	compiled := testKB(t)
+	probe, err := compiled.UseProbe("ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testRunConfig(t)
+	cfg.Prompt = probe.Prompt
+	cfg.Probe = probe.Name
+
+	report, err := Run(&bytes.Buffer{}, cfg, compiled, Options{
+		Format: FormatJSON,
+		Models: []string{"test/good"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	res := report.Results[0]
+	if res.Category != models.CategoryAvailable || res.Probe != "ok" {
+		t.Errorf("Expected AVAILABLE via probe ok, got %s via %q (%s)", res.Category, res.Probe, res.Output)
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	installFakeOpencode(t)
+
+	dir := t.TempDir()
+	compiled := testKB(t)
+	cfg := testRunConfig(t)
+	w, err := checkpoint.Create(dir, checkpoint.Header{
+		RunID:  "run1",
+		Prompt: cfg.Prompt,
+		KBHash: compiled.Fingerprint(),
+		Models: []string{"test/good", "test/auth"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Append(models.ModelResult{Model: "test/good", Category: models.CategoryAvailable, Reason: "checkpoint"})
+	w.Close()
+
+	run, err := checkpoint.Load(dir, "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Run(&bytes.Buffer{}, cfg, compiled, Options{
+		Format:        FormatJSON,
+		CheckpointDir: dir,
+		Resume:        &run,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(report.Results) != 2 || report.Results[0].Reason != "checkpoint" {
+		t.Errorf("Expected the saved result followed by test/auth, got %+v", report.Results)
+	}
+	if report.Results[1].Category != models.CategoryAuthFailed || report.RunID != "run1" {
+		t.Errorf("Expected test/auth probed under run1, got %s in %q", report.Results[1].Category, report.RunID)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "run1.jsonl")); !os.IsNotExist(err) {
+		t.Error("Completed run should delete its checkpoint")
+	}
+}
+
+func TestRunRecordsHistory(t *testing.T) {
+	installFakeOpencode(t)
+
+	runCfg := testRunConfig(t)
+	runCfg.HistoryPath = filepath.Join(t.TempDir(), "history.json")
+	if _, err := Run(&bytes.Buffer{}, runCfg, testKB(t), Options{Format: FormatNDJSON}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	hist, err := history.Load(runCfg.HistoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := hist["test/good"]; e.LastCategory != models.CategoryAvailable || e.Successes != 1 {
+		t.Errorf("Unexpected history for test/good: %+v", e)
+	}
+	if e := hist["test/auth"]; e.LastCategory != models.CategoryAuthFailed || e.Successes != 0 {
+		t.Errorf("Unexpected history for test/auth: %+v", e)
+	}
+}
+
+func TestRunIgnoresBrokenHistory(t *testing.T) {
+	installFakeOpencode(t)
+
+	runCfg := testRunConfig(t)
+	runCfg.HistoryPath = filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(runCfg.HistoryPath, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	report, err := Run(&buf, runCfg, testKB(t), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("A broken history should not fail the run: %v", err)
+	}
+	if len(report.Results) != 2 || buf.Len() == 0 {
+		t.Errorf("Expected the report to be written, got %d results", len(report.Results))
+	}
+}
+
+func TestRunInterrupted(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "opencode"), []byte("#!/bin/sh\nexec "+sleep+" 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	dir := t.TempDir()
+	runCfg := testRunConfig(t)
+	runCfg.Timeout = 30 * time.Second
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+	}()
+
+	start := time.Now()
+	report, err := Run(&bytes.Buffer{}, runCfg, testKB(t), Options{
+		Format:        FormatNDJSON,
+		Models:        []string{"test/a", "test/b", "test/c"},
+		CheckpointDir: dir,
+	})
+	if err != nil {
+		t.Fatalf("An interrupted run should end normally: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Interrupt should cancel running probes, run took %s", elapsed)
+	}
+	if !report.Interrupted || report.RunID == "" {
+		t.Fatalf("Expected an interrupted report with a run ID, got %+v", report)
+	}
+	if _, err := checkpoint.Load(dir, report.RunID); err != nil {
+		t.Errorf("The run file should be kept for --resume: %v", err)
+	}
+}
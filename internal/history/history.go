@@ -0,0 +1,118 @@
+// Package history keeps a per-model summary of past runs: the last verdict
+// and how often the model answered. The KB priority rules use it to order
+// the test queue.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"llm-radar/internal/models"
+)
+
+// Entry summarizes the past probes of one model.
+type Entry struct {
+	LastCategory string `json:"last_category"`
+	LastProbe    string `json:"last_probe"` // timestamp of the last recorded result
+	Probes       int    `json:"probes"`
+	Successes    int    `json:"successes"`
+}
+
+// SuccessRate returns the fraction of past probes with a usable verdict.
+func (e Entry) SuccessRate() float64 {
+	if e.Probes == 0 {
+		return 0
+	}
+	return float64(e.Successes) / float64(e.Probes)
+}
+
+// History maps model names to their past probes.
+type History map[string]Entry
+
+// Record adds the results of a run. Skipped results were never probed and
+// are ignored, as are results already recorded, such as those replayed
+// from the cache or a resumed run. Multi-sample results count every sample.
+func (h History) Record(results []models.ModelResult) {
+	for _, res := range results {
+		e := h[res.Model]
+		if res.Skipped || (res.Timestamp != "" && res.Timestamp == e.LastProbe) {
+			continue
+		}
+
+		if s := res.Samples; s != nil {
+			e.Probes += s.Count
+			e.Successes += s.Successes
+		} else {
+			e.Probes++
+			if models.IsUsable(res.Category) {
+				e.Successes++
+			}
+		}
+		e.LastCategory = res.Category
+		e.LastProbe = res.Timestamp
+		h[res.Model] = e
+	}
+}
+
+// ============================================================================
+// PERSISTENCE
+// ============================================================================
+
+// Load reads the history at path. A missing file is an empty history.
+func Load(path string) (History, error) {
+	h := make(History)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler histórico: %w", err)
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("histórico inválido: %w", err)
+	}
+	return h, nil
+}
+
+// Save writes the history to path, replacing the file atomically.
+func (h History) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório do histórico: %w", err)
+	}
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".history-*.tmp")
+	if err != nil {
+		return fmt.Errorf("erro ao gravar histórico: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao gravar histórico: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("erro ao gravar histórico: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("erro ao gravar histórico: %w", err)
+	}
+	return nil
+}
+
+// Update records the results of a run into the history at path.
+func Update(path string, results []models.ModelResult) error {
+	h, err := Load(path)
+	if err != nil {
+		return err
+	}
+	h.Record(results)
+	return h.Save(path)
+}
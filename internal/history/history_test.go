@@ -0,0 +1,70 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+
+	"llm-radar/internal/models"
+)
+
+func TestRecord(t *testing.T) {
+	h := make(History)
+	h.Record([]models.ModelResult{
+		{Model: "a/1", Category: models.CategoryAvailable, Timestamp: "t1"},
+		{Model: "b/2", Category: models.CategoryAuthFailed, Timestamp: "t1"},
+		{Model: "c/3", Category: models.CategoryAuthFailed, Skipped: true, Timestamp: "t1"},
+	})
+	h.Record([]models.ModelResult{
+		{Model: "a/1", Category: models.CategoryTimeout, Timestamp: "t2"},
+		{Model: "b/2", Category: models.CategoryAuthFailed, Timestamp: "t1"}, // replayed from cache
+	})
+
+	if e := h["a/1"]; e.Probes != 2 || e.Successes != 1 || e.LastCategory != models.CategoryTimeout {
+		t.Errorf("Unexpected entry for a/1: %+v", e)
+	}
+	if e := h["b/2"]; e.Probes != 1 {
+		t.Errorf("Expected a replayed result to be recorded once, got %d probes", e.Probes)
+	}
+	if _, ok := h["c/3"]; ok {
+		t.Error("Expected skipped results to be ignored")
+	}
+	if rate := h["a/1"].SuccessRate(); rate != 0.5 {
+		t.Errorf("Expected success rate 0.5, got %v", rate)
+	}
+}
+
+func TestRecordSamples(t *testing.T) {
+	h := make(History)
+	h.Record([]models.ModelResult{{
+		Model:     "a/1",
+		Category:  models.CategoryAvailable,
+		Samples:   &models.SampleStats{Count: 5, Successes: 4},
+		Timestamp: "t1",
+	}})
+
+	if e := h["a/1"]; e.Probes != 5 || e.Successes != 4 {
+		t.Errorf("Expected 4/5 from samples, got %d/%d", e.Successes, e.Probes)
+	}
+}
+
+func TestUpdateAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "history.json")
+
+	h, err := Load(path)
+	if err != nil || len(h) != 0 {
+		t.Fatalf("Expected an empty history for a missing file, got %v (%v)", h, err)
+	}
+
+	results := []models.ModelResult{{Model: "a/1", Category: models.CategoryFree, Timestamp: "t1"}}
+	if err := Update(path, results); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	h, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if e := h["a/1"]; e.LastCategory != models.CategoryFree || e.Successes != 1 {
+		t.Errorf("Unexpected entry after reload: %+v", e)
+	}
+}
@@ -0,0 +1,158 @@
+// Package junit renders run results as JUnit XML for CI dashboards.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"llm-radar/internal/models"
+	"llm-radar/internal/policy"
+)
+
+// ============================================================================
+// XML STRUCTURES
+// ============================================================================
+
+type testSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr,omitempty"`
+	Time     string      `xml:"time,attr"`
+	Suites   []testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Skipped   int        `xml:"skipped,attr,omitempty"`
+	Time      string     `xml:"time,attr"`
+	Timestamp string     `xml:"timestamp,attr,omitempty"`
+	Cases     []testCase `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *failure `xml:"failure,omitempty"`
+	Skipped   *skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+type skipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ============================================================================
+// RENDERING
+// ============================================================================
+
+// Write renders one testcase per model, failing every model whose category
+// is not usable. Models left unprobed by the run deadline are skipped
+// rather than failed. Policy violations are reported in a separate suite.
+func Write(w io.Writer, name string, results []models.ModelResult, violations []policy.Violation) error {
+	modelSuite := testSuite{Name: "models"}
+	var totalMs int64
+
+	for _, r := range results {
+		tc := testCase{
+			Name:      r.Model,
+			ClassName: r.Provider,
+			Time:      seconds(r.DurationMs),
+			SystemOut: r.Output,
+		}
+		if r.Category == models.CategorySkipped {
+			tc.Skipped = &skipped{Message: r.Reason}
+			modelSuite.Skipped++
+		} else if !models.IsUsable(r.Category) {
+			tc.Failure = &failure{
+				Message: fmt.Sprintf("%s: %s", r.Category, r.Reason),
+				Type:    r.Category,
+				Text:    fmt.Sprintf("exit code %d", r.ExitCode),
+			}
+			modelSuite.Failures++
+		}
+		if modelSuite.Timestamp == "" {
+			modelSuite.Timestamp = r.Timestamp
+		}
+		modelSuite.Cases = append(modelSuite.Cases, tc)
+		totalMs += r.DurationMs
+	}
+	modelSuite.Tests = len(modelSuite.Cases)
+	modelSuite.Time = seconds(totalMs)
+
+	doc := testSuites{
+		Name:     name,
+		Tests:    modelSuite.Tests,
+		Failures: modelSuite.Failures,
+		Skipped:  modelSuite.Skipped,
+		Time:     modelSuite.Time,
+		Suites:   []testSuite{modelSuite},
+	}
+
+	if len(violations) > 0 {
+		policySuite := testSuite{Name: "policy", Time: seconds(0)}
+		for _, v := range violations {
+			policySuite.Cases = append(policySuite.Cases, testCase{
+				Name:      v.Rule,
+				ClassName: "policy",
+				Time:      seconds(0),
+				Failure: &failure{
+					Message: v.Message,
+					Type:    "PolicyViolation",
+				},
+			})
+		}
+		policySuite.Tests = len(policySuite.Cases)
+		policySuite.Failures = len(policySuite.Cases)
+
+		doc.Tests += policySuite.Tests
+		doc.Failures += policySuite.Failures
+		doc.Suites = append(doc.Suites, policySuite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("erro ao gerar JUnit XML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteFile renders the JUnit report to path, creating parent directories.
+func WriteFile(path, name string, results []models.ModelResult, violations []policy.Violation) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(f, name, results, violations); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
@@ -0,0 +1,83 @@
+package junit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"llm-radar/internal/models"
+	"llm-radar/internal/policy"
+)
+
+func TestWrite(t *testing.T) {
+	results := []models.ModelResult{
+		{Model: "opencode/big-pickle", Provider: "opencode", Category: models.CategoryFree, DurationMs: 1500},
+		{Model: "anthropic/claude", Provider: "anthropic", Category: models.CategoryAuthFailed, Reason: "API key inválida", ExitCode: 1},
+	}
+	violations := []policy.Violation{{Rule: "FREE>=2", Message: "esperado pelo menos 2"}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "LLM Radar", results, violations); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var doc testSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Output is not valid XML: %v", err)
+	}
+
+	if doc.Tests != 3 || doc.Failures != 2 {
+		t.Errorf("Expected 3 tests and 2 failures, got %d/%d", doc.Tests, doc.Failures)
+	}
+	if len(doc.Suites) != 2 {
+		t.Fatalf("Expected models and policy suites, got %d", len(doc.Suites))
+	}
+
+	modelCases := doc.Suites[0].Cases
+	if modelCases[0].Failure != nil {
+		t.Error("Usable model should pass")
+	}
+	if modelCases[1].Failure == nil || modelCases[1].Failure.Type != models.CategoryAuthFailed {
+		t.Error("AUTH_FAILED model should fail with its category as type")
+	}
+	if modelCases[0].Time != "1.500" {
+		t.Errorf("Expected time 1.500, got %s", modelCases[0].Time)
+	}
+}
+
+func TestWriteWithoutViolations(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "LLM Radar", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc testSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Suites) != 1 {
+		t.Errorf("Policy suite should be omitted without violations, got %d suites", len(doc.Suites))
+	}
+}
+
+func TestWriteSkipped(t *testing.T) {
+	results := []models.ModelResult{
+		{Model: "groq/llama", Provider: "groq", Category: models.CategorySkipped, Reason: "Ignorado: prazo da execução esgotado (1m30s)", Skipped: true},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "LLM Radar", results, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc testSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Failures != 0 || doc.Skipped != 1 {
+		t.Errorf("Expected 0 failures and 1 skipped, got %d/%d", doc.Failures, doc.Skipped)
+	}
+	if tc := doc.Suites[0].Cases[0]; tc.Skipped == nil || tc.Failure != nil {
+		t.Error("Deadline-skipped model should be reported as skipped, not failed")
+	}
+}
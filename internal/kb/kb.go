@@ -2,11 +2,19 @@
 package kb
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"llm-radar/internal/filter"
 	"llm-radar/internal/models"
 )
 
@@ -16,14 +24,99 @@ import (
 
 // Config holds the knowledge base configuration.
 type Config struct {
-	FreeModels        map[string]ModelInfo    `json:"free_models"`
-	FreeTierProviders map[string]ProviderInfo `json:"free_tier_providers"`
-	SuccessRegex      string                  `json:"success_regex"`
-	NotFoundRegex     string                  `json:"not_found_regex"`
-	AuthRegex         string                  `json:"auth_regex"`
-	QuotaRegex        string                  `json:"quota_regex"`
-	RateLimitRegex    string                  `json:"rate_limit_regex"`
-	TimeoutRegex      string                  `json:"timeout_regex"`
+	FreeModels        map[string]ModelInfo     `json:"free_models"`
+	FreeTierProviders map[string]ProviderInfo  `json:"free_tier_providers"`
+	ProviderLimits    map[string]ProviderLimit `json:"provider_limits,omitempty"`
+	SuccessRegex      string                   `json:"success_regex"`
+	NotFoundRegex     string                   `json:"not_found_regex"`
+	AuthRegex         string                   `json:"auth_regex"`
+	QuotaRegex        string                   `json:"quota_regex"`
+	RateLimitRegex    string                   `json:"rate_limit_regex"`
+	TimeoutRegex      string                   `json:"timeout_regex"`
+	// RetryHintRegexes extract how long to wait from rate-limit output. Each
+	// pattern needs a named group: "seconds" (a number), "duration" (a Go
+	// duration such as 1m30s) or "reset" (a unix or RFC 3339 timestamp).
+	RetryHintRegexes []string `json:"retry_hint_regexes,omitempty"`
+	// MaxRetryWait is the longest hinted wait honored in place; longer
+	// hints requeue the model at the end of the run.
+	MaxRetryWait string `json:"max_retry_wait,omitempty"`
+	// RetryPolicies sets how failed attempts are retried, per category.
+	// Categories not listed are never retried.
+	RetryPolicies map[string]RetryPolicy   `json:"retry_policies,omitempty"`
+	CacheTTL      map[string]string        `json:"cache_ttl,omitempty"`
+	Probes        map[string]ProbeConfig   `json:"probes,omitempty"`
+	Backends      map[string]BackendConfig `json:"backends,omitempty"`
+	// ProviderBackends selects the backend used for each provider. The "*"
+	// entry applies to providers not listed; without it, BackendOpencode is used.
+	ProviderBackends map[string]string `json:"provider_backends,omitempty"`
+	// Timeouts override the run timeout for models matching a pattern. The
+	// first matching rule applies; see Compiled.TimeoutFor for precedence.
+	Timeouts []TimeoutRule `json:"timeouts,omitempty"`
+	// Priority orders the test queue. Each model is queued with the first
+	// rule it matches; models matching none go last, and the discovery
+	// order is kept within each group.
+	Priority []PriorityRule `json:"priority,omitempty"`
+}
+
+// Backend types.
+const (
+	BackendOpencode = "opencode"
+	BackendOpenAI   = "openai"
+	BackendCommand  = "command"
+)
+
+// DefaultBackendProvider is the ProviderBackends key matching any provider.
+const DefaultBackendProvider = "*"
+
+// BackendConfig defines a named probe backend.
+type BackendConfig struct {
+	Type string `json:"type"`
+	// BaseURL is the OpenAI-compatible API root, e.g.
+	// https://api.groq.com/openai/v1 (openai backends).
+	BaseURL string `json:"base_url,omitempty"`
+	// APIKeyEnv names the environment variable holding the API key. When
+	// empty, requests are sent without authentication (openai backends).
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+
+	// Command is the probe command template (command backends), e.g.
+	// "ollama run {name} {prompt}". It is split on whitespace and run
+	// without a shell; placeholders are {model}, {provider}, {name} (the
+	// model without the provider prefix) and {prompt}.
+	Command string `json:"command,omitempty"`
+	// Discover lists models for each provider using this backend, e.g.
+	// "ollama list". The {provider} placeholder is available.
+	Discover string `json:"discover,omitempty"`
+	// DiscoverRegex extracts the model name from each line of the discovery
+	// output (first capture group, or the whole match). Defaults to the
+	// first whitespace-separated field.
+	DiscoverRegex string `json:"discover_regex,omitempty"`
+
+	// Optional overrides of the global classification regexes for output
+	// produced by this backend.
+	SuccessRegex   string `json:"success_regex,omitempty"`
+	NotFoundRegex  string `json:"not_found_regex,omitempty"`
+	AuthRegex      string `json:"auth_regex,omitempty"`
+	QuotaRegex     string `json:"quota_regex,omitempty"`
+	RateLimitRegex string `json:"rate_limit_regex,omitempty"`
+	TimeoutRegex   string `json:"timeout_regex,omitempty"`
+}
+
+// backendPatterns holds a backend's compiled regex overrides; nil entries
+// inherit the global patterns.
+type backendPatterns struct {
+	success, notFound, auth, quota, rateLimit, timeout *regexp.Regexp
+	discover                                           *regexp.Regexp
+}
+
+// ProbeConfig defines a named probe: the prompt sent to the model and how a
+// valid answer is recognized. Set at most one of SuccessRegex and Exact; with
+// neither, the global SuccessRegex is used.
+type ProbeConfig struct {
+	Prompt       string `json:"prompt"`
+	SuccessRegex string `json:"success_regex,omitempty"`
+	// Exact requires the whole output, ignoring surrounding whitespace, to
+	// equal this text.
+	Exact string `json:"exact,omitempty"`
 }
 
 // ModelInfo describes a model in the knowledge base.
@@ -31,6 +124,7 @@ type ModelInfo struct {
 	Category    string `json:"category"`
 	Description string `json:"description"`
 	Limits      string `json:"limits,omitempty"`
+	Timeout     string `json:"timeout,omitempty"` // overrides the run timeout
 }
 
 // ProviderInfo describes a provider in the knowledge base.
@@ -38,19 +132,125 @@ type ProviderInfo struct {
 	Category    string `json:"category"`
 	Description string `json:"description"`
 	Limits      string `json:"limits"`
+	Timeout     string `json:"timeout,omitempty"` // overrides the run timeout
+}
+
+// TimeoutRule sets the probe timeout of the models matching any of its
+// globs or "re:" regexes. It is the way to time paid models, since only
+// free models have a ModelInfo entry.
+type TimeoutRule struct {
+	Models  []string `json:"models"`
+	Timeout string   `json:"timeout"`
+}
+
+// timeoutRule is a compiled TimeoutRule.
+type timeoutRule struct {
+	filter  filter.Filter
+	timeout time.Duration
+}
+
+// ProviderLimit caps how hard a provider is probed. Zero values mean no limit.
+type ProviderLimit struct {
+	// MaxInFlight is the maximum number of concurrent probes.
+	MaxInFlight int `json:"max_in_flight,omitempty"`
+	// RPM is the maximum number of probes started per minute.
+	RPM int `json:"rpm,omitempty"`
+	// Burst is how many probes may start at once before RPM pacing
+	// applies (default 1).
+	Burst int `json:"burst,omitempty"`
+}
+
+// PriorityRule selects models to test early. Every field that is set must
+// match; a rule with no fields matches every model.
+type PriorityRule struct {
+	// Models are globs or "re:" regexes matched against the full model name.
+	Models    []string `json:"models,omitempty"`
+	Providers []string `json:"providers,omitempty"`
+	// Free matches the models listed in FreeModels.
+	Free bool `json:"free,omitempty"`
+	// LastCategories matches models whose last recorded verdict is one of
+	// these categories.
+	LastCategories []string `json:"last_categories,omitempty"`
+	// MinSuccessRate matches models usable in at least this fraction (0 to
+	// 1) of their past probes. Models never probed do not match.
+	MinSuccessRate float64 `json:"min_success_rate,omitempty"`
+}
+
+// Priority is a compiled PriorityRule.
+type Priority struct {
+	Filter         filter.Filter // models and providers
+	Free           bool
+	LastCategories map[string]bool
+	MinSuccessRate float64
+}
+
+// RetryPolicy controls how failed attempts of one category are retried.
+// The delay before retry n (1-based) is BaseDelay * Multiplier^(n-1),
+// randomized by ±Jitter.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts ending in this category,
+	// including the first. 0 uses the run's retries setting plus one.
+	MaxAttempts int    `json:"max_attempts,omitempty"`
+	BaseDelay   string `json:"base_delay,omitempty"`
+	// Multiplier grows the delay on each retry (default 1, a fixed delay).
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// Jitter is the fraction of each delay that is randomized, 0 to 1.
+	Jitter float64 `json:"jitter,omitempty"`
+	// Timeout replaces the run timeout for retries, e.g. to give a model
+	// that timed out more time.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Retry is a compiled RetryPolicy.
+type Retry struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	Multiplier  float64
+	Jitter      float64
+	Timeout     time.Duration
+}
+
+// Delay returns the wait before retry n (1-based). r in [0, 1) picks the
+// jitter, so callers control the randomness.
+func (p Retry) Delay(n int, r float64) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(p.Multiplier, float64(n-1))
+	d *= 1 + p.Jitter*(2*r-1)
+	return time.Duration(d)
 }
 
 // Compiled holds both the config and compiled regex patterns.
 type Compiled struct {
-	Config      Config
-	SuccessRe   *regexp.Regexp
-	NotFoundRe  *regexp.Regexp
-	AuthRe      *regexp.Regexp
-	QuotaRe     *regexp.Regexp
-	RateLimitRe *regexp.Regexp
-	TimeoutRe   *regexp.Regexp
+	Config       Config
+	SuccessRe    *regexp.Regexp
+	NotFoundRe   *regexp.Regexp
+	AuthRe       *regexp.Regexp
+	QuotaRe      *regexp.Regexp
+	RateLimitRe  *regexp.Regexp
+	TimeoutRe    *regexp.Regexp
+	RetryHints   []*regexp.Regexp
+	MaxRetryWait time.Duration
+	CacheTTLs    map[string]time.Duration
+	Probes       map[string]Probe
+	Retries      map[string]Retry
+	Priorities   []Priority
+
+	backendPatterns  map[string]backendPatterns
+	modelTimeouts    map[string]time.Duration
+	providerTimeouts map[string]time.Duration
+	timeoutRules     []timeoutRule
+}
+
+// Probe is a compiled named probe.
+type Probe struct {
+	Name      string
+	Prompt    string
+	SuccessRe *regexp.Regexp
 }
 
+// DefaultProbe is the probe that keeps the run prompt and the global
+// SuccessRegex, unless the KB redefines it.
+const DefaultProbe = "default"
+
 // ============================================================================
 // DEFAULT CONFIGURATION
 // ============================================================================
@@ -109,12 +309,60 @@ func DefaultConfig() Config {
 			},
 		},
 
+		ProviderLimits: map[string]ProviderLimit{
+			"cerebras": {MaxInFlight: 2, RPM: 30},
+			"deepseek": {MaxInFlight: 2, RPM: 50},
+			"groq":     {MaxInFlight: 2, RPM: 30},
+		},
+
 		SuccessRegex:   `(?i)(^|\b)(2\s*,?\s*3\s*,?\s*5|prime|primos|OK)(\b|$)`,
 		NotFoundRegex:  `(?i)(404|not\.found|entity.was.not.found|modelnotfounderror)`,
 		AuthRegex:      `(?i)(auth|unauthoriz|api\.?key|invalid.*key|401|403)`,
 		QuotaRegex:     `(?i)(insufficient.*quota|quota.*exceed|no.*credits?|billing.*limit)`,
 		RateLimitRegex: `(?i)(rate.limit|too.many.*request|throttl|429)`,
 		TimeoutRegex:   `(?i)(timeout|timed.out|deadline.exceeded)`,
+
+		RetryHintRegexes: []string{
+			`(?i)retry[- ]after["':\s]+(?P<seconds>\d+(?:\.\d+)?)`,
+			`(?i)try again in (?P<duration>(?:\d+(?:\.\d+)?(?:ms|h|m|s))+)`,
+			`(?i)ratelimit[- ]reset[a-z-]*["':\s]+(?P<reset>\d{10})\b`,
+			`(?i)resets? at (?P<reset>\d{4}-\d{2}-\d{2}T[0-9:.]+(?:Z|[+-]\d{2}:\d{2}))`,
+		},
+		MaxRetryWait: "30s",
+
+		// Rate limits back off exponentially; jitter keeps concurrent
+		// workers from retrying in lockstep.
+		RetryPolicies: map[string]RetryPolicy{
+			models.CategoryRateLimited: {BaseDelay: "500ms", Multiplier: 2, Jitter: 0.2},
+		},
+
+		// Transient failures are never reused; stable verdicts outlive the
+		// default expiry. Categories not listed use the default.
+		CacheTTL: map[string]string{
+			models.CategoryTimeout:     "0s",
+			models.CategoryRateLimited: "0s",
+			models.CategoryError:       "0s",
+			models.CategoryFreeError:   "0s",
+			models.CategoryNoQuota:     "6h",
+			models.CategoryAuthFailed:  "72h",
+			models.CategoryNotFound:    "72h",
+		},
+
+		// Known free models first, then the team's coding plan.
+		Priority: []PriorityRule{
+			{Free: true},
+			{Providers: []string{"zai-coding-plan"}},
+		},
+
+		Probes: map[string]ProbeConfig{
+			"en": {
+				Prompt: "Reply with only: 2, 3, 5",
+			},
+			"ok": {
+				Prompt: "Reply with exactly one word: OK",
+				Exact:  "OK",
+			},
+		},
 	}
 }
 
@@ -174,9 +422,322 @@ func Compile(cfg Config) (Compiled, error) {
 		return ckb, fmt.Errorf("regex TimeoutRegex inválida: %w", err)
 	}
 
+	ckb.RetryHints, ckb.MaxRetryWait, err = compileRetryHints(cfg.RetryHintRegexes, cfg.MaxRetryWait)
+	if err != nil {
+		return ckb, err
+	}
+
+	ckb.Retries, err = compileRetryPolicies(cfg.RetryPolicies)
+	if err != nil {
+		return ckb, err
+	}
+
+	ckb.modelTimeouts, ckb.providerTimeouts, ckb.timeoutRules, err = compileTimeouts(cfg)
+	if err != nil {
+		return ckb, err
+	}
+
+	ckb.Priorities, err = compilePriorities(cfg.Priority)
+	if err != nil {
+		return ckb, err
+	}
+
+	ckb.CacheTTLs, err = parseCacheTTLs(cfg.CacheTTL)
+	if err != nil {
+		return ckb, err
+	}
+
+	ckb.Probes, err = compileProbes(cfg.Probes, ckb.SuccessRe)
+	if err != nil {
+		return ckb, err
+	}
+
+	for provider, l := range cfg.ProviderLimits {
+		if l.MaxInFlight < 0 || l.RPM < 0 || l.Burst < 0 {
+			return ckb, fmt.Errorf("provider_limits[%s]: valores não podem ser negativos", provider)
+		}
+	}
+
+	if err := validateBackends(cfg.Backends, cfg.ProviderBackends); err != nil {
+		return ckb, err
+	}
+
+	ckb.backendPatterns, err = compileBackendPatterns(cfg.Backends)
+	if err != nil {
+		return ckb, err
+	}
+
 	return ckb, nil
 }
 
+// parseCacheTTLs validates category names and parses TTL durations.
+func parseCacheTTLs(raw map[string]string) (map[string]time.Duration, error) {
+	known := make(map[string]bool)
+	for _, cat := range models.AllCategories() {
+		known[cat] = true
+	}
+
+	ttls := make(map[string]time.Duration, len(raw))
+	for cat, value := range raw {
+		if !known[cat] {
+			return nil, fmt.Errorf("cache_ttl: categoria desconhecida %q", cat)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("cache_ttl[%s] inválido: %w", cat, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("cache_ttl[%s] não pode ser negativo", cat)
+		}
+		ttls[cat] = d
+	}
+	return ttls, nil
+}
+
+// retryHintGroups are the named groups a retry hint regex may capture.
+var retryHintGroups = []string{"seconds", "duration", "reset"}
+
+// compileRetryHints compiles the retry hint patterns and the maximum wait.
+func compileRetryHints(patterns []string, maxWait string) ([]*regexp.Regexp, time.Duration, error) {
+	var hints []*regexp.Regexp
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, 0, fmt.Errorf("retry_hint_regexes[%d] inválida: %w", i, err)
+		}
+		hasGroup := false
+		for _, g := range retryHintGroups {
+			hasGroup = hasGroup || re.SubexpIndex(g) >= 0
+		}
+		if !hasGroup {
+			return nil, 0, fmt.Errorf("retry_hint_regexes[%d]: falta grupo nomeado (seconds, duration ou reset)", i)
+		}
+		hints = append(hints, re)
+	}
+
+	var limit time.Duration
+	if maxWait != "" {
+		var err error
+		if limit, err = time.ParseDuration(maxWait); err != nil || limit < 0 {
+			return nil, 0, fmt.Errorf("max_retry_wait inválido: %q", maxWait)
+		}
+	}
+	return hints, limit, nil
+}
+
+// compileRetryPolicies validates category names and parses each policy.
+func compileRetryPolicies(raw map[string]RetryPolicy) (map[string]Retry, error) {
+	known := make(map[string]bool)
+	for _, cat := range models.AllCategories() {
+		known[cat] = true
+	}
+
+	parse := func(cat, name, value string) (time.Duration, error) {
+		if value == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("retry_policies[%s].%s inválido: %q", cat, name, value)
+		}
+		return d, nil
+	}
+
+	retries := make(map[string]Retry, len(raw))
+	for cat, p := range raw {
+		if !known[cat] {
+			return nil, fmt.Errorf("retry_policies: categoria desconhecida %q", cat)
+		}
+		if p.MaxAttempts < 0 || p.Multiplier < 0 || p.Jitter < 0 || p.Jitter > 1 {
+			return nil, fmt.Errorf("retry_policies[%s]: max_attempts e multiplier não podem ser negativos e jitter deve estar entre 0 e 1", cat)
+		}
+
+		r := Retry{MaxAttempts: p.MaxAttempts, Multiplier: p.Multiplier, Jitter: p.Jitter}
+		if r.Multiplier == 0 {
+			r.Multiplier = 1
+		}
+		var err error
+		if r.BaseDelay, err = parse(cat, "base_delay", p.BaseDelay); err != nil {
+			return nil, err
+		}
+		if r.Timeout, err = parse(cat, "timeout", p.Timeout); err != nil {
+			return nil, err
+		}
+		retries[cat] = r
+	}
+	return retries, nil
+}
+
+// compileTimeouts parses the timeout overrides of models, providers and
+// timeout rules.
+func compileTimeouts(cfg Config) (map[string]time.Duration, map[string]time.Duration, []timeoutRule, error) {
+	parse := func(where, value string) (time.Duration, error) {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("%s.timeout inválido: %q", where, value)
+		}
+		return d, nil
+	}
+
+	modelTimeouts := make(map[string]time.Duration)
+	for model, info := range cfg.FreeModels {
+		if info.Timeout == "" {
+			continue
+		}
+		d, err := parse(fmt.Sprintf("free_models[%s]", model), info.Timeout)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		modelTimeouts[model] = d
+	}
+
+	providerTimeouts := make(map[string]time.Duration)
+	for provider, info := range cfg.FreeTierProviders {
+		if info.Timeout == "" {
+			continue
+		}
+		d, err := parse(fmt.Sprintf("free_tier_providers[%s]", provider), info.Timeout)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		providerTimeouts[provider] = d
+	}
+
+	rules := make([]timeoutRule, 0, len(cfg.Timeouts))
+	for i, r := range cfg.Timeouts {
+		if len(r.Models) == 0 {
+			return nil, nil, nil, fmt.Errorf("timeouts[%d]: models vazio", i)
+		}
+		f, err := filter.New(nil, r.Models, nil)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("timeouts[%d]: %w", i, err)
+		}
+		d, err := parse(fmt.Sprintf("timeouts[%d]", i), r.Timeout)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		rules = append(rules, timeoutRule{filter: f, timeout: d})
+	}
+	return modelTimeouts, providerTimeouts, rules, nil
+}
+
+// compilePriorities validates the priority rules and compiles their patterns.
+func compilePriorities(rules []PriorityRule) ([]Priority, error) {
+	known := make(map[string]bool)
+	for _, cat := range models.AllCategories() {
+		known[cat] = true
+	}
+
+	priorities := make([]Priority, 0, len(rules))
+	for i, r := range rules {
+		f, err := filter.New(r.Providers, r.Models, nil)
+		if err != nil {
+			return nil, fmt.Errorf("priority[%d]: %w", i, err)
+		}
+		if r.MinSuccessRate < 0 || r.MinSuccessRate > 1 {
+			return nil, fmt.Errorf("priority[%d]: min_success_rate deve estar entre 0 e 1", i)
+		}
+
+		p := Priority{Filter: f, Free: r.Free, MinSuccessRate: r.MinSuccessRate}
+		if len(r.LastCategories) > 0 {
+			p.LastCategories = make(map[string]bool, len(r.LastCategories))
+			for _, cat := range r.LastCategories {
+				if !known[cat] {
+					return nil, fmt.Errorf("priority[%d]: categoria desconhecida %q", i, cat)
+				}
+				p.LastCategories[cat] = true
+			}
+		}
+		priorities = append(priorities, p)
+	}
+	return priorities, nil
+}
+
+// compileProbes validates probe definitions and compiles their success rules.
+func compileProbes(raw map[string]ProbeConfig, defaultRe *regexp.Regexp) (map[string]Probe, error) {
+	probes := make(map[string]Probe, len(raw))
+	for name, pc := range raw {
+		if pc.Prompt == "" {
+			return nil, fmt.Errorf("probes[%s]: prompt vazio", name)
+		}
+
+		probe := Probe{Name: name, Prompt: pc.Prompt, SuccessRe: defaultRe}
+		switch {
+		case pc.SuccessRegex != "" && pc.Exact != "":
+			return nil, fmt.Errorf("probes[%s]: use success_regex ou exact, não ambos", name)
+		case pc.SuccessRegex != "":
+			re, err := regexp.Compile(pc.SuccessRegex)
+			if err != nil {
+				return nil, fmt.Errorf("probes[%s]: regex inválida: %w", name, err)
+			}
+			probe.SuccessRe = re
+		case pc.Exact != "":
+			probe.SuccessRe = regexp.MustCompile(`^\s*` + regexp.QuoteMeta(pc.Exact) + `\s*$`)
+		}
+		probes[name] = probe
+	}
+	return probes, nil
+}
+
+// validateBackends checks backend types and that every provider refers to a
+// defined backend. The built-in "opencode" backend needs no definition.
+func validateBackends(backends map[string]BackendConfig, providers map[string]string) error {
+	for name, b := range backends {
+		switch b.Type {
+		case BackendOpencode:
+		case BackendOpenAI:
+			if b.BaseURL == "" {
+				return fmt.Errorf("backends[%s]: base_url obrigatório para %s", name, b.Type)
+			}
+		case BackendCommand:
+			if strings.TrimSpace(b.Command) == "" {
+				return fmt.Errorf("backends[%s]: command obrigatório para %s", name, b.Type)
+			}
+		default:
+			return fmt.Errorf("backends[%s]: tipo desconhecido %q", name, b.Type)
+		}
+	}
+
+	for provider, name := range providers {
+		if _, ok := backends[name]; !ok && name != BackendOpencode {
+			return fmt.Errorf("provider_backends[%s]: backend indefinido %q", provider, name)
+		}
+	}
+	return nil
+}
+
+// compileBackendPatterns compiles the per-backend regex overrides.
+func compileBackendPatterns(backends map[string]BackendConfig) (map[string]backendPatterns, error) {
+	compiled := make(map[string]backendPatterns, len(backends))
+	for name, b := range backends {
+		var p backendPatterns
+		for _, o := range []struct {
+			field string
+			expr  string
+			dst   **regexp.Regexp
+		}{
+			{"success_regex", b.SuccessRegex, &p.success},
+			{"not_found_regex", b.NotFoundRegex, &p.notFound},
+			{"auth_regex", b.AuthRegex, &p.auth},
+			{"quota_regex", b.QuotaRegex, &p.quota},
+			{"rate_limit_regex", b.RateLimitRegex, &p.rateLimit},
+			{"timeout_regex", b.TimeoutRegex, &p.timeout},
+			{"discover_regex", b.DiscoverRegex, &p.discover},
+		} {
+			if o.expr == "" {
+				continue
+			}
+			re, err := regexp.Compile(o.expr)
+			if err != nil {
+				return nil, fmt.Errorf("backends[%s]: %s inválida: %w", name, o.field, err)
+			}
+			*o.dst = re
+		}
+		compiled[name] = p
+	}
+	return compiled, nil
+}
+
 // ============================================================================
 // LOOKUP METHODS
 // ============================================================================
@@ -192,3 +753,159 @@ func (c *Compiled) GetFreeTierProvider(provider string) (ProviderInfo, bool) {
 	info, ok := c.Config.FreeTierProviders[provider]
 	return info, ok
 }
+
+// UseProbe makes the named probe's success rule the one used to validate
+// responses and returns it. An empty name selects DefaultProbe, which has no
+// prompt of its own unless the KB defines one.
+func (c *Compiled) UseProbe(name string) (Probe, error) {
+	if name == "" {
+		name = DefaultProbe
+	}
+
+	probe, ok := c.Probes[name]
+	if !ok {
+		if name != DefaultProbe {
+			return Probe{}, fmt.Errorf("probe desconhecido %q (disponíveis: %s)", name, strings.Join(c.ProbeNames(), ", "))
+		}
+		probe = Probe{Name: DefaultProbe, SuccessRe: c.SuccessRe}
+	}
+
+	c.SuccessRe = probe.SuccessRe
+	return probe, nil
+}
+
+// ProbeNames returns the available probe names, sorted.
+func (c *Compiled) ProbeNames() []string {
+	names := []string{DefaultProbe}
+	for name := range c.Probes {
+		if name != DefaultProbe {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// BackendFor returns the backend name and definition used for a provider.
+func (c *Compiled) BackendFor(provider string) (string, BackendConfig) {
+	name, ok := c.Config.ProviderBackends[provider]
+	if !ok {
+		name, ok = c.Config.ProviderBackends[DefaultBackendProvider]
+	}
+	if !ok {
+		name = BackendOpencode
+	}
+
+	if b, ok := c.Config.Backends[name]; ok {
+		return name, b
+	}
+	return name, BackendConfig{Type: BackendOpencode}
+}
+
+// ForModel returns the KB used to classify a model's output: the global
+// patterns with any overrides from the backend of the model's provider.
+// A backend's success_regex takes precedence over the selected probe.
+func (c Compiled) ForModel(model string) Compiled {
+	provider, _, _ := strings.Cut(model, "/")
+	name, _ := c.BackendFor(provider)
+
+	p, ok := c.backendPatterns[name]
+	if !ok {
+		return c
+	}
+	for _, o := range []struct {
+		src *regexp.Regexp
+		dst **regexp.Regexp
+	}{
+		{p.success, &c.SuccessRe},
+		{p.notFound, &c.NotFoundRe},
+		{p.auth, &c.AuthRe},
+		{p.quota, &c.QuotaRe},
+		{p.rateLimit, &c.RateLimitRe},
+		{p.timeout, &c.TimeoutRe},
+	} {
+		if o.src != nil {
+			*o.dst = o.src
+		}
+	}
+	return c
+}
+
+// TimeoutFor returns the probe timeout of a model, from the most specific
+// override: its free_models entry, the first matching timeouts rule, then
+// its provider's free_tier_providers entry. Without any, it returns
+// fallback, the run timeout.
+func (c *Compiled) TimeoutFor(model string, fallback time.Duration) time.Duration {
+	if d, ok := c.modelTimeouts[model]; ok {
+		return d
+	}
+	for _, r := range c.timeoutRules {
+		if r.filter.Match(model) {
+			return r.timeout
+		}
+	}
+	provider, _, _ := strings.Cut(model, "/")
+	if d, ok := c.providerTimeouts[provider]; ok {
+		return d
+	}
+	return fallback
+}
+
+// RetryAfter extracts a wait hint from rate-limit output using the first
+// matching retry hint pattern. Reset timestamps are converted to the time
+// left from now; a reset in the past yields a zero wait.
+func (c *Compiled) RetryAfter(output string, now time.Time) (time.Duration, bool) {
+	for _, re := range c.RetryHints {
+		m := re.FindStringSubmatch(output)
+		if m == nil {
+			continue
+		}
+
+		if i := re.SubexpIndex("seconds"); i >= 0 && m[i] != "" {
+			if secs, err := strconv.ParseFloat(m[i], 64); err == nil {
+				return time.Duration(secs * float64(time.Second)), true
+			}
+		}
+		if i := re.SubexpIndex("duration"); i >= 0 && m[i] != "" {
+			if d, err := time.ParseDuration(m[i]); err == nil {
+				return d, true
+			}
+		}
+		if i := re.SubexpIndex("reset"); i >= 0 && m[i] != "" {
+			if at, ok := parseResetTime(m[i]); ok {
+				return max(at.Sub(now), 0), true
+			}
+		}
+	}
+	return 0, false
+}
+
+func parseResetTime(s string) (time.Time, bool) {
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0), true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// DiscoverRegex returns the regex extracting model names from a backend's
+// discovery output, or nil to use the first field of each line.
+func (c *Compiled) DiscoverRegex(backend string) *regexp.Regexp {
+	return c.backendPatterns[backend].discover
+}
+
+// Fingerprint returns a stable hash of the configuration, used to invalidate
+// cached results when the knowledge base changes.
+func (c *Compiled) Fingerprint() string {
+	// Priority rules only change the queue order, never a verdict.
+	cfg := c.Config
+	cfg.Priority = nil
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
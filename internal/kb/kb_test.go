@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"llm-radar/internal/models"
 )
@@ -286,3 +287,367 @@ func TestInvalidRegexReturnsError(t *testing.T) {
 		t.Error("Expected error for invalid regex")
 	}
 }
+
+func TestFingerprintChangesWithConfig(t *testing.T) {
+	a, _ := Compile(DefaultConfig())
+	b, _ := Compile(DefaultConfig())
+
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("Identical configs should have the same fingerprint")
+	}
+
+	cfg := DefaultConfig()
+	cfg.SuccessRegex = "(?i)success"
+	c, _ := Compile(cfg)
+
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Error("Changing the config should change the fingerprint")
+	}
+}
+
+func TestCacheTTLsParsed(t *testing.T) {
+	compiled, err := Compile(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl, ok := compiled.CacheTTLs[models.CategoryRateLimited]; !ok || ttl != 0 {
+		t.Errorf("Expected zero TTL for RATE_LIMITED, got %v (ok=%v)", ttl, ok)
+	}
+	if ttl := compiled.CacheTTLs[models.CategoryAuthFailed]; ttl != 72*time.Hour {
+		t.Errorf("Expected 72h TTL for AUTH_FAILED, got %v", ttl)
+	}
+}
+
+func TestInvalidCacheTTLReturnsError(t *testing.T) {
+	tests := map[string]string{
+		"UNKNOWN_CATEGORY":         "1h",
+		models.CategoryTimeout:     "soon",
+		models.CategoryRateLimited: "-1m",
+	}
+
+	for cat, value := range tests {
+		cfg := DefaultConfig()
+		cfg.CacheTTL = map[string]string{cat: value}
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("Expected error for cache_ttl[%s]=%q", cat, value)
+		}
+	}
+}
+
+func TestUseProbeDefault(t *testing.T) {
+	compiled, _ := Compile(DefaultConfig())
+	defaultRe := compiled.SuccessRe
+
+	probe, err := compiled.UseProbe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probe.Name != DefaultProbe || probe.Prompt != "" {
+		t.Errorf("Expected default probe without prompt, got %+v", probe)
+	}
+	if compiled.SuccessRe != defaultRe {
+		t.Error("Default probe should keep the global SuccessRegex")
+	}
+}
+
+func TestUseProbeExact(t *testing.T) {
+	compiled, _ := Compile(DefaultConfig())
+
+	probe, err := compiled.UseProbe("ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probe.Prompt == "" {
+		t.Error("Named probe should carry its prompt")
+	}
+
+	tests := map[string]bool{
+		"OK":        true,
+		"  OK\n":    true,
+		"OK, sure":  false,
+		"2, 3, 5":   false,
+		"It is OK.": false,
+	}
+	for output, want := range tests {
+		if got := compiled.SuccessRe.MatchString(output); got != want {
+			t.Errorf("Exact match %q = %v, want %v", output, got, want)
+		}
+	}
+}
+
+func TestUseProbeRegex(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Probes = map[string]ProbeConfig{
+		"colors": {Prompt: "Name a primary color", SuccessRegex: `(?i)\b(red|blue|yellow)\b`},
+	}
+	compiled, err := Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := compiled.UseProbe("colors"); err != nil {
+		t.Fatal(err)
+	}
+	if !compiled.SuccessRe.MatchString("Blue") || compiled.SuccessRe.MatchString("2, 3, 5") {
+		t.Error("Probe regex should replace the global SuccessRegex")
+	}
+}
+
+func TestUseProbeUnknown(t *testing.T) {
+	compiled, _ := Compile(DefaultConfig())
+	if _, err := compiled.UseProbe("missing"); err == nil {
+		t.Error("Expected error for unknown probe")
+	}
+}
+
+func TestInvalidProbeReturnsError(t *testing.T) {
+	tests := map[string]ProbeConfig{
+		"no prompt":  {SuccessRegex: "ok"},
+		"bad regex":  {Prompt: "p", SuccessRegex: "[invalid"},
+		"both rules": {Prompt: "p", SuccessRegex: "ok", Exact: "OK"},
+	}
+
+	for name, pc := range tests {
+		cfg := DefaultConfig()
+		cfg.Probes = map[string]ProbeConfig{"p": pc}
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestBackendFor(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Backends = map[string]BackendConfig{"cli": {Type: BackendOpencode}}
+	cfg.ProviderBackends = map[string]string{"groq": "cli"}
+	compiled, err := Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name, _ := compiled.BackendFor("groq"); name != "cli" {
+		t.Errorf("Expected cli for groq, got %s", name)
+	}
+	if name, b := compiled.BackendFor("openai"); name != BackendOpencode || b.Type != BackendOpencode {
+		t.Errorf("Expected built-in opencode backend, got %s (%+v)", name, b)
+	}
+
+	cfg.ProviderBackends[DefaultBackendProvider] = "cli"
+	compiled, _ = Compile(cfg)
+	if name, _ := compiled.BackendFor("openai"); name != "cli" {
+		t.Errorf("Expected wildcard backend, got %s", name)
+	}
+}
+
+func TestInvalidBackendReturnsError(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Backends = map[string]BackendConfig{"x": {Type: "carrier-pigeon"}}
+	if _, err := Compile(cfg); err == nil {
+		t.Error("Expected error for unknown backend type")
+	}
+
+	cfg = DefaultConfig()
+	cfg.ProviderBackends = map[string]string{"groq": "missing"}
+	if _, err := Compile(cfg); err == nil {
+		t.Error("Expected error for undefined backend")
+	}
+}
+
+func TestForModelAppliesBackendOverrides(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Backends = map[string]BackendConfig{
+		"local": {Type: BackendCommand, Command: "ollama run {name} {prompt}", SuccessRegex: `^done$`},
+	}
+	cfg.ProviderBackends = map[string]string{"ollama": "local"}
+	compiled, err := Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := compiled.ForModel("ollama/llama3")
+	if !local.SuccessRe.MatchString("done") || local.SuccessRe.MatchString("2, 3, 5") {
+		t.Error("Backend success_regex should override the global one")
+	}
+	if local.AuthRe != compiled.AuthRe {
+		t.Error("Patterns without overrides should be inherited")
+	}
+	if other := compiled.ForModel("groq/llama"); other.SuccessRe != compiled.SuccessRe {
+		t.Error("Other providers should keep the global patterns")
+	}
+}
+
+func TestInvalidCommandBackendReturnsError(t *testing.T) {
+	tests := map[string]BackendConfig{
+		"no command":    {Type: BackendCommand},
+		"bad regex":     {Type: BackendCommand, Command: "llm", AuthRegex: "[invalid"},
+		"bad discovery": {Type: BackendCommand, Command: "llm", DiscoverRegex: "("},
+	}
+	for name, b := range tests {
+		cfg := DefaultConfig()
+		cfg.Backends = map[string]BackendConfig{"x": b}
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestInvalidProviderLimitReturnsError(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProviderLimits = map[string]ProviderLimit{"groq": {RPM: -1}}
+	if _, err := Compile(cfg); err == nil {
+		t.Error("Expected error for negative RPM")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	compiled, _ := Compile(DefaultConfig())
+	now := time.Unix(1_800_000_000, 0)
+
+	tests := []struct {
+		output string
+		want   time.Duration
+		ok     bool
+	}{
+		{"Rate limit exceeded, retry after 12s", 12 * time.Second, true},
+		{"HTTP 429: {}\nRetry-After: 3", 3 * time.Second, true},
+		{"Please try again in 1m30.5s.", 90*time.Second + 500*time.Millisecond, true},
+		{"x-ratelimit-reset: 1800000045", 45 * time.Second, true},
+		{"quota resets at 2027-01-15T08:00:00Z", time.Date(2027, 1, 15, 8, 0, 0, 0, time.UTC).Sub(now), true},
+		{"x-ratelimit-reset: 1700000000", 0, true},
+		{"429 too many requests", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := compiled.RetryAfter(tt.output, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("RetryAfter(%q) = %v, %v; want %v, %v", tt.output, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestInvalidRetryHintReturnsError(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RetryHintRegexes = []string{`retry after (\d+)`}
+	if _, err := Compile(cfg); err == nil {
+		t.Error("Expected error for retry hint without a named group")
+	}
+
+	cfg = DefaultConfig()
+	cfg.MaxRetryWait = "forever"
+	if _, err := Compile(cfg); err == nil {
+		t.Error("Expected error for invalid max_retry_wait")
+	}
+}
+
+func TestRetryPolicies(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RetryPolicies[models.CategoryTimeout] = RetryPolicy{MaxAttempts: 2, BaseDelay: "1s", Timeout: "60s"}
+	compiled, err := Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := compiled.Retries[models.CategoryTimeout]
+	if timeout.MaxAttempts != 2 || timeout.Timeout != 60*time.Second || timeout.Multiplier != 1 {
+		t.Errorf("Unexpected TIMEOUT policy: %+v", timeout)
+	}
+	if _, ok := compiled.Retries[models.CategoryRateLimited]; !ok {
+		t.Error("Default RATE_LIMITED policy should be kept")
+	}
+
+	rl := Retry{BaseDelay: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.5}
+	if d := rl.Delay(3, 0.5); d != 400*time.Millisecond {
+		t.Errorf("Expected 400ms before the third retry, got %v", d)
+	}
+	if d := rl.Delay(3, 0); d != 200*time.Millisecond {
+		t.Errorf("Expected -50%% jitter to give 200ms, got %v", d)
+	}
+}
+
+func TestInvalidRetryPolicyReturnsError(t *testing.T) {
+	tests := map[string]RetryPolicy{
+		"BOGUS":                    {MaxAttempts: 2},
+		models.CategoryError:       {BaseDelay: "soon"},
+		models.CategoryTimeout:     {Jitter: 1.5},
+		models.CategoryRateLimited: {MaxAttempts: -1},
+	}
+	for cat, p := range tests {
+		cfg := DefaultConfig()
+		cfg.RetryPolicies = map[string]RetryPolicy{cat: p}
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("Expected error for %s policy %+v", cat, p)
+		}
+	}
+}
+
+func TestInvalidPriorityRuleReturnsError(t *testing.T) {
+	tests := []PriorityRule{
+		{Models: []string{"re:("}},
+		{LastCategories: []string{"BOGUS"}},
+		{MinSuccessRate: 1.5},
+	}
+	for _, r := range tests {
+		cfg := DefaultConfig()
+		cfg.Priority = []PriorityRule{r}
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("Expected error for priority rule %+v", r)
+		}
+	}
+}
+
+func TestTimeoutFor(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FreeModels["opencode/big-pickle"] = ModelInfo{Category: models.CategoryFree, Timeout: "5s"}
+	cfg.FreeTierProviders["groq"] = ProviderInfo{Category: models.CategoryFreeLimited, Timeout: "10s"}
+	cfg.Timeouts = []TimeoutRule{
+		{Models: []string{"*reasoner*", "re:/o[0-9]"}, Timeout: "60s"},
+		{Models: []string{"*"}, Timeout: "30s"},
+	}
+	compiled, err := Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]time.Duration{
+		"opencode/big-pickle":    5 * time.Second,  // model entry wins
+		"deepseek/deep-reasoner": 60 * time.Second, // first matching rule
+		"openai/o3":              60 * time.Second,
+		"groq/llama":             30 * time.Second, // rules before provider
+		"other/model":            30 * time.Second,
+	}
+	for model, want := range tests {
+		if got := compiled.TimeoutFor(model, 20*time.Second); got != want {
+			t.Errorf("TimeoutFor(%s) = %s, want %s", model, got, want)
+		}
+	}
+
+	cfg.Timeouts = nil
+	compiled, err = Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := compiled.TimeoutFor("groq/llama", 20*time.Second); got != 10*time.Second {
+		t.Errorf("Expected the provider timeout, got %s", got)
+	}
+	if got := compiled.TimeoutFor("other/model", 20*time.Second); got != 20*time.Second {
+		t.Errorf("Expected the run timeout, got %s", got)
+	}
+}
+
+func TestInvalidTimeoutReturnsError(t *testing.T) {
+	tests := []func(*Config){
+		func(c *Config) { c.FreeModels["opencode/big-pickle"] = ModelInfo{Timeout: "soon"} },
+		func(c *Config) { c.FreeTierProviders["groq"] = ProviderInfo{Timeout: "-1s"} },
+		func(c *Config) { c.Timeouts = []TimeoutRule{{Timeout: "10s"}} },
+		func(c *Config) { c.Timeouts = []TimeoutRule{{Models: []string{"re:("}, Timeout: "10s"}} },
+		func(c *Config) { c.Timeouts = []TimeoutRule{{Models: []string{"*"}, Timeout: "0s"}} },
+	}
+	for i, mutate := range tests {
+		cfg := DefaultConfig()
+		mutate(&cfg)
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("Case %d: expected error", i)
+		}
+	}
+}
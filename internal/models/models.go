@@ -2,21 +2,99 @@
 package models
 
 import (
+	"math"
+	"sort"
 	"time"
 )
 
 // ModelResult represents the outcome of availability test for a single model.
 type ModelResult struct {
-	Model      string `json:"model"`
-	Provider   string `json:"provider"`
+	Model      string       `json:"model"`
+	Provider   string       `json:"provider"`
+	Category   string       `json:"category"`
+	Reason     string       `json:"reason"`
+	Duration   string       `json:"duration"`
+	DurationMs int64        `json:"duration_ms"`
+	Output     string       `json:"output,omitempty"`
+	ExitCode   int          `json:"exit_code"`
+	Icon       string       `json:"icon"`
+	Probe      string       `json:"probe,omitempty"`
+	Waited     bool         `json:"waited,omitempty"`  // verdict came after a rate-limit wait
+	WaitMs     int64        `json:"wait_ms,omitempty"` // total hinted wait
+	Skipped    bool         `json:"skipped,omitempty"` // not probed; category inferred
+	Attempts   []Attempt    `json:"attempts,omitempty"`
+	Samples    *SampleStats `json:"samples,omitempty"` // set when probed more than once
+	Timestamp  string       `json:"timestamp"`
+}
+
+// Attempt records one probe of a model. A result has one per attempt, in
+// order; the last one produced the result's verdict.
+type Attempt struct {
 	Category   string `json:"category"`
-	Reason     string `json:"reason"`
-	Duration   string `json:"duration"`
-	DurationMs int64  `json:"duration_ms"`
-	Output     string `json:"output,omitempty"`
 	ExitCode   int    `json:"exit_code"`
-	Icon       string `json:"icon"`
-	Timestamp  string `json:"timestamp"`
+	DurationMs int64  `json:"duration_ms"`
+	BackoffMs  int64  `json:"backoff_ms,omitempty"` // wait before the next attempt
+	Output     string `json:"output,omitempty"`     // trimmed
+}
+
+// SampleStats aggregates repeated probes of one model (RunConfig.Samples).
+type SampleStats struct {
+	Count        int            `json:"count"`
+	Successes    int            `json:"successes"`
+	SuccessRatio float64        `json:"success_ratio"`
+	P50Ms        int64          `json:"p50_ms"`
+	P95Ms        int64          `json:"p95_ms"`
+	MaxMs        int64          `json:"max_ms"`
+	Majority     string         `json:"majority_category"`
+	Categories   map[string]int `json:"categories"`
+}
+
+// AggregateSamples computes reliability stats over results of the same
+// model. Latency percentiles use the nearest-rank method; a tie for the
+// majority category goes to the category that reached the count first.
+func AggregateSamples(samples []ModelResult) SampleStats {
+	stats := SampleStats{Count: len(samples), Categories: make(map[string]int)}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	durations := make([]int64, 0, len(samples))
+	for _, s := range samples {
+		stats.Categories[s.Category]++
+		if IsUsable(s.Category) {
+			stats.Successes++
+		}
+		durations = append(durations, s.DurationMs)
+		if n := stats.Categories[s.Category]; n > stats.Categories[stats.Majority] {
+			stats.Majority = s.Category
+		}
+	}
+	stats.SuccessRatio = float64(stats.Successes) / float64(len(samples))
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	percentile := func(p float64) int64 {
+		rank := int(math.Ceil(p * float64(len(durations))))
+		return durations[max(rank, 1)-1]
+	}
+	stats.P50Ms = percentile(0.50)
+	stats.P95Ms = percentile(0.95)
+	stats.MaxMs = durations[len(durations)-1]
+	return stats
+}
+
+// MergeSamples reduces several results of the same model to one: the last
+// sample in the majority category, with the aggregate stats attached.
+func MergeSamples(samples []ModelResult) ModelResult {
+	stats := AggregateSamples(samples)
+
+	var merged ModelResult
+	for _, s := range samples {
+		if s.Category == stats.Majority {
+			merged = s
+		}
+	}
+	merged.Samples = &stats
+	return merged
 }
 
 // ModelInfo contains metadata about a specific model.
@@ -35,20 +113,35 @@ type ProviderInfo struct {
 
 // RunConfig holds the configuration for a test execution.
 type RunConfig struct {
-	Prompt      string
-	Timeout     time.Duration
-	Concurrency int
-	Retries     int
-	MaxOutputKB int
-	UseCache    bool
-	CachePath   string
+	Prompt          string
+	Probe           string
+	Timeout         time.Duration
+	Concurrency     int
+	Retries         int
+	MaxOutputKB     int
+	UseCache        bool
+	CachePath       string
+	HistoryPath     string // past verdicts used by the KB priority rules
+	OpencodeVersion string
+	Serve           string // "auto" or the URL of a running `opencode serve`
+	CircuitBreaker  int    // consecutive auth/quota failures that skip a provider; 0 disables
+	Samples         int    // probes per model; more than 1 adds SampleStats
+	SampleInterval  time.Duration
+	Deadline        time.Duration // bound on the whole probing phase; 0 disables
+	ExportFormat    string
+	ExportPath      string
+	Providers       []string
+	Include         []string
+	Exclude         []string
 }
 
 // CachedResult wraps a ModelResult with metadata for caching purposes.
 type CachedResult struct {
-	Result    ModelResult `json:"result"`
-	CachedAt  time.Time   `json:"cached_at"`
-	ExpiresAt time.Time   `json:"expires_at"`
+	Result      ModelResult `json:"result"`
+	Fingerprint string      `json:"fingerprint"`
+	Scope       string      `json:"scope,omitempty"` // fingerprint of the run identity only (see cache.Key)
+	CachedAt    time.Time   `json:"cached_at"`
+	ExpiresAt   time.Time   `json:"expires_at"`
 }
 
 // IsExpired checks if the cached result has exceeded its lifespan.
@@ -56,6 +149,35 @@ func (cr *CachedResult) IsExpired() bool {
 	return time.Now().After(cr.ExpiresAt)
 }
 
+// RunSummary aggregates the results of a run by category.
+type RunSummary struct {
+	Total      int            `json:"total"`
+	Usable     int            `json:"usable"`
+	Categories map[string]int `json:"categories"`
+	DurationMs int64          `json:"duration_ms"`
+}
+
+// Summarize computes category counts, usable models and total probe time.
+func Summarize(results []ModelResult) RunSummary {
+	summary := RunSummary{
+		Total:      len(results),
+		Categories: make(map[string]int),
+	}
+	for _, r := range results {
+		summary.Categories[r.Category]++
+		summary.DurationMs += r.DurationMs
+		if IsUsable(r.Category) {
+			summary.Usable++
+		}
+	}
+	return summary
+}
+
+// IsUsable reports whether a category means the model answered correctly.
+func IsUsable(category string) bool {
+	return CategorySeverity(category) == SeveritySuccess
+}
+
 // Model categories constants.
 const (
 	CategoryFree        = "FREE"
@@ -69,6 +191,7 @@ const (
 	CategoryRateLimited = "RATE_LIMITED"
 	CategoryFreeError   = "FREE_ERROR"
 	CategoryError       = "ERROR"
+	CategorySkipped     = "SKIPPED"
 )
 
 // CategoryIcons provides a visual representation for each category.
@@ -84,6 +207,42 @@ var CategoryIcons = map[string]string{
 	CategoryRateLimited: "⏱️",
 	CategoryFreeError:   "⚠️",
 	CategoryError:       "⚠️",
+	CategorySkipped:     "⏭️",
+}
+
+// CategoryDescriptions provides a short human-readable meaning for each category.
+var CategoryDescriptions = map[string]string{
+	CategoryFree:        "Gratuito sem limites conhecidos",
+	CategoryFreeLimited: "Gratuito com quotas documentadas",
+	CategoryPaid:        "Pago com créditos ativos",
+	CategoryAvailable:   "Disponível",
+	CategoryNotFound:    "Modelo não existe",
+	CategoryTimeout:     "Sem resposta dentro do timeout",
+	CategoryAuthFailed:  "API key inválida",
+	CategoryNoQuota:     "Sem créditos",
+	CategoryRateLimited: "Rate limit atingido",
+	CategoryFreeError:   "Modelo gratuito falhou no teste",
+	CategoryError:       "Erro desconhecido",
+	CategorySkipped:     "Não testado antes do prazo da execução",
+}
+
+// Category severities, used to color categories consistently across outputs.
+const (
+	SeveritySuccess = "success"
+	SeverityWarning = "warning"
+	SeverityDanger  = "danger"
+)
+
+// CategorySeverity returns the severity of a category.
+func CategorySeverity(category string) string {
+	switch category {
+	case CategoryFree, CategoryFreeLimited, CategoryPaid, CategoryAvailable:
+		return SeveritySuccess
+	case CategoryTimeout, CategoryNotFound, CategoryRateLimited, CategorySkipped:
+		return SeverityWarning
+	default:
+		return SeverityDanger
+	}
 }
 
 // AllCategories returns a list of all supported categories.
@@ -100,5 +259,6 @@ func AllCategories() []string {
 		CategoryRateLimited,
 		CategoryFreeError,
 		CategoryError,
+		CategorySkipped,
 	}
 }
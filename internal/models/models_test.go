@@ -16,9 +16,17 @@ func TestCategoryIconsComplete(t *testing.T) {
 	}
 }
 
+func TestCategoryDescriptionsComplete(t *testing.T) {
+	for _, cat := range AllCategories() {
+		if _, ok := CategoryDescriptions[cat]; !ok {
+			t.Errorf("Category %q is missing a description", cat)
+		}
+	}
+}
+
 func TestAllCategoriesCount(t *testing.T) {
 	categories := AllCategories()
-	expected := 11
+	expected := 12
 
 	if len(categories) != expected {
 		t.Errorf("Expected %d categories, got %d", expected, len(categories))
@@ -101,3 +109,68 @@ func TestRunConfigDefaults(t *testing.T) {
 		t.Error("Timeout should be at least 1 second")
 	}
 }
+
+func TestSummarize(t *testing.T) {
+	results := []ModelResult{
+		{Model: "a/free", Category: CategoryFree, DurationMs: 100},
+		{Model: "a/avail", Category: CategoryAvailable, DurationMs: 200},
+		{Model: "a/auth", Category: CategoryAuthFailed, DurationMs: 300},
+	}
+
+	summary := Summarize(results)
+
+	if summary.Total != 3 {
+		t.Errorf("Expected total 3, got %d", summary.Total)
+	}
+	if summary.Usable != 2 {
+		t.Errorf("Expected 2 usable, got %d", summary.Usable)
+	}
+	if summary.Categories[CategoryAuthFailed] != 1 {
+		t.Errorf("Expected 1 AUTH_FAILED, got %d", summary.Categories[CategoryAuthFailed])
+	}
+	if summary.DurationMs != 600 {
+		t.Errorf("Expected 600ms total, got %d", summary.DurationMs)
+	}
+}
+
+func TestAggregateSamples(t *testing.T) {
+	samples := []ModelResult{
+		{Category: CategoryAvailable, DurationMs: 400},
+		{Category: CategoryTimeout, DurationMs: 2000},
+		{Category: CategoryAvailable, DurationMs: 100},
+		{Category: CategoryAvailable, DurationMs: 300},
+		{Category: CategoryRateLimited, DurationMs: 200},
+	}
+
+	stats := AggregateSamples(samples)
+
+	if stats.Count != 5 || stats.Successes != 3 {
+		t.Errorf("Expected 3/5 successes, got %d/%d", stats.Successes, stats.Count)
+	}
+	if stats.SuccessRatio != 0.6 {
+		t.Errorf("Expected success ratio 0.6, got %v", stats.SuccessRatio)
+	}
+	if stats.P50Ms != 300 || stats.P95Ms != 2000 || stats.MaxMs != 2000 {
+		t.Errorf("Expected p50 300, p95 2000, max 2000; got %d, %d, %d", stats.P50Ms, stats.P95Ms, stats.MaxMs)
+	}
+	if stats.Majority != CategoryAvailable {
+		t.Errorf("Expected majority AVAILABLE, got %s", stats.Majority)
+	}
+}
+
+func TestMergeSamples(t *testing.T) {
+	samples := []ModelResult{
+		{Model: "a/m", Category: CategoryAvailable, Reason: "first"},
+		{Model: "a/m", Category: CategoryTimeout, Reason: "timeout"},
+		{Model: "a/m", Category: CategoryAvailable, Reason: "last"},
+	}
+
+	merged := MergeSamples(samples)
+
+	if merged.Category != CategoryAvailable || merged.Reason != "last" {
+		t.Errorf("Expected the last AVAILABLE sample, got %s (%s)", merged.Category, merged.Reason)
+	}
+	if merged.Samples == nil || merged.Samples.Count != 3 {
+		t.Errorf("Expected stats for 3 samples, got %+v", merged.Samples)
+	}
+}
@@ -0,0 +1,245 @@
+// Package policy evaluates run results against CI gating rules.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"llm-radar/internal/models"
+)
+
+// Usable is a pseudo-category matching every usable model
+// (FREE, FREE_LIMITED, PAID and AVAILABLE).
+const Usable = "USABLE"
+
+// Process exit codes used when gating a run.
+const (
+	ExitOK            = 0
+	ExitError         = 1
+	ExitUsage         = 2
+	ExitCountViolated = 3
+	ExitModelViolated = 4
+)
+
+// ============================================================================
+// POLICY STRUCTURES
+// ============================================================================
+
+// Policy describes the conditions a run must satisfy.
+type Policy struct {
+	MinCategories  map[string]int `json:"min_categories,omitempty"`
+	MaxCategories  map[string]int `json:"max_categories,omitempty"`
+	RequiredModels []string       `json:"required_models,omitempty"`
+}
+
+// Violation describes a single rule that was not satisfied.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	// Model is set when the violated rule concerns a specific model.
+	Model string `json:"model,omitempty"`
+}
+
+// IsEmpty reports whether the policy has no rules.
+func (p Policy) IsEmpty() bool {
+	return len(p.MinCategories) == 0 && len(p.MaxCategories) == 0 && len(p.RequiredModels) == 0
+}
+
+// ============================================================================
+// LOADING AND PARSING
+// ============================================================================
+
+// Load reads a JSON policy file.
+func Load(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("erro ao ler política: %w", err)
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("erro ao parsear política: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// Parse reads a comma-separated list of rules, for example
+// "FREE>=2,USABLE>=5,AUTH_FAILED<=0,model:opencode/big-pickle".
+func Parse(expr string) (Policy, error) {
+	var p Policy
+
+	for _, raw := range strings.Split(expr, ",") {
+		rule := strings.TrimSpace(raw)
+		if rule == "" {
+			continue
+		}
+
+		if model, ok := strings.CutPrefix(rule, "model:"); ok {
+			model = strings.TrimSpace(model)
+			if model == "" {
+				return Policy{}, fmt.Errorf("regra inválida %q: modelo vazio", rule)
+			}
+			p.RequiredModels = append(p.RequiredModels, model)
+			continue
+		}
+
+		op := ">="
+		idx := strings.Index(rule, op)
+		if idx < 0 {
+			op = "<="
+			idx = strings.Index(rule, op)
+		}
+		if idx < 0 {
+			return Policy{}, fmt.Errorf("regra inválida %q: use CATEGORIA>=N, CATEGORIA<=N ou model:NOME", rule)
+		}
+
+		cat := strings.ToUpper(strings.TrimSpace(rule[:idx]))
+		n, err := strconv.Atoi(strings.TrimSpace(rule[idx+len(op):]))
+		if err != nil || n < 0 {
+			return Policy{}, fmt.Errorf("regra inválida %q: quantidade deve ser um inteiro >= 0", rule)
+		}
+
+		if op == ">=" {
+			if p.MinCategories == nil {
+				p.MinCategories = make(map[string]int)
+			}
+			p.MinCategories[cat] = n
+		} else {
+			if p.MaxCategories == nil {
+				p.MaxCategories = make(map[string]int)
+			}
+			p.MaxCategories[cat] = n
+		}
+	}
+
+	if err := p.validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// Merge combines two policies; rules in o override rules in p.
+func (p Policy) Merge(o Policy) Policy {
+	merged := Policy{
+		MinCategories: make(map[string]int),
+		MaxCategories: make(map[string]int),
+	}
+	for _, src := range []Policy{p, o} {
+		for k, v := range src.MinCategories {
+			merged.MinCategories[k] = v
+		}
+		for k, v := range src.MaxCategories {
+			merged.MaxCategories[k] = v
+		}
+		merged.RequiredModels = append(merged.RequiredModels, src.RequiredModels...)
+	}
+	return merged
+}
+
+func (p Policy) validate() error {
+	known := map[string]bool{Usable: true}
+	for _, cat := range models.AllCategories() {
+		known[cat] = true
+	}
+
+	for _, rules := range []map[string]int{p.MinCategories, p.MaxCategories} {
+		for cat := range rules {
+			if !known[cat] {
+				return fmt.Errorf("política: categoria desconhecida %q", cat)
+			}
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// EVALUATION
+// ============================================================================
+
+// Evaluate checks the run summary and results against the policy.
+// Violations are returned in a stable order.
+func (p Policy) Evaluate(summary models.RunSummary, results []models.ModelResult) []Violation {
+	var violations []Violation
+
+	for _, cat := range sortedKeys(p.MinCategories) {
+		want := p.MinCategories[cat]
+		if got := countFor(summary, cat); got < want {
+			violations = append(violations, Violation{
+				Rule:    fmt.Sprintf("%s>=%d", cat, want),
+				Message: fmt.Sprintf("esperado pelo menos %d modelos %s, obtido %d", want, cat, got),
+			})
+		}
+	}
+
+	for _, cat := range sortedKeys(p.MaxCategories) {
+		want := p.MaxCategories[cat]
+		if got := countFor(summary, cat); got > want {
+			violations = append(violations, Violation{
+				Rule:    fmt.Sprintf("%s<=%d", cat, want),
+				Message: fmt.Sprintf("esperado no máximo %d modelos %s, obtido %d", want, cat, got),
+			})
+		}
+	}
+
+	byModel := make(map[string]models.ModelResult, len(results))
+	for _, r := range results {
+		byModel[r.Model] = r
+	}
+
+	for _, model := range p.RequiredModels {
+		rule := "model:" + model
+		res, ok := byModel[model]
+		switch {
+		case !ok:
+			violations = append(violations, Violation{
+				Rule:    rule,
+				Model:   model,
+				Message: fmt.Sprintf("modelo %s não foi testado", model),
+			})
+		case !models.IsUsable(res.Category):
+			violations = append(violations, Violation{
+				Rule:    rule,
+				Model:   model,
+				Message: fmt.Sprintf("modelo %s não utilizável: %s (%s)", model, res.Category, res.Reason),
+			})
+		}
+	}
+
+	return violations
+}
+
+// ExitCode maps violations to a process exit code. Required-model
+// violations take precedence over category count violations.
+func ExitCode(violations []Violation) int {
+	code := ExitOK
+	for _, v := range violations {
+		if v.Model != "" {
+			return ExitModelViolated
+		}
+		code = ExitCountViolated
+	}
+	return code
+}
+
+func countFor(summary models.RunSummary, cat string) int {
+	if cat == Usable {
+		return summary.Usable
+	}
+	return summary.Categories[cat]
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"llm-radar/internal/models"
+)
+
+func sampleResults() []models.ModelResult {
+	return []models.ModelResult{
+		{Model: "opencode/big-pickle", Category: models.CategoryFree},
+		{Model: "opencode/gpt-5-nano", Category: models.CategoryFree},
+		{Model: "groq/llama", Category: models.CategoryFreeLimited},
+		{Model: "anthropic/claude", Category: models.CategoryAuthFailed, Reason: "API key inválida"},
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse("FREE>=2, usable>=3,AUTH_FAILED<=0,model:opencode/big-pickle")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if p.MinCategories[models.CategoryFree] != 2 {
+		t.Errorf("Expected FREE>=2, got %v", p.MinCategories)
+	}
+	if p.MinCategories[Usable] != 3 {
+		t.Errorf("Expected USABLE>=3, got %v", p.MinCategories)
+	}
+	if v, ok := p.MaxCategories[models.CategoryAuthFailed]; !ok || v != 0 {
+		t.Errorf("Expected AUTH_FAILED<=0, got %v", p.MaxCategories)
+	}
+	if len(p.RequiredModels) != 1 || p.RequiredModels[0] != "opencode/big-pickle" {
+		t.Errorf("Unexpected required models: %v", p.RequiredModels)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"FREE", "FREE>=x", "BOGUS>=1", "model:", "FREE>=-1"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	p, err := Parse("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsEmpty() {
+		t.Error("Empty expression should produce an empty policy")
+	}
+}
+
+func TestEvaluatePasses(t *testing.T) {
+	p, _ := Parse("FREE>=2,USABLE>=3,model:groq/llama")
+	results := sampleResults()
+
+	violations := p.Evaluate(models.Summarize(results), results)
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+	if code := ExitCode(violations); code != ExitOK {
+		t.Errorf("Expected exit %d, got %d", ExitOK, code)
+	}
+}
+
+func TestEvaluateCountViolation(t *testing.T) {
+	p, _ := Parse("FREE>=3,AUTH_FAILED<=0")
+	results := sampleResults()
+
+	violations := p.Evaluate(models.Summarize(results), results)
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %v", violations)
+	}
+	if code := ExitCode(violations); code != ExitCountViolated {
+		t.Errorf("Expected exit %d, got %d", ExitCountViolated, code)
+	}
+}
+
+func TestEvaluateRequiredModel(t *testing.T) {
+	p, _ := Parse("FREE>=3,model:anthropic/claude,model:missing/model")
+	results := sampleResults()
+
+	violations := p.Evaluate(models.Summarize(results), results)
+	if len(violations) != 3 {
+		t.Fatalf("Expected 3 violations, got %v", violations)
+	}
+	if code := ExitCode(violations); code != ExitModelViolated {
+		t.Errorf("Required model violations should win, got exit %d", code)
+	}
+}
+
+func TestLoadAndMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	content := `{"min_categories": {"FREE": 1}, "required_models": ["opencode/big-pickle"]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fromFile, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	inline, _ := Parse("FREE>=5")
+
+	merged := fromFile.Merge(inline)
+	if merged.MinCategories[models.CategoryFree] != 5 {
+		t.Errorf("Inline rule should override file rule, got %v", merged.MinCategories)
+	}
+	if len(merged.RequiredModels) != 1 {
+		t.Errorf("Expected required models from file, got %v", merged.RequiredModels)
+	}
+}
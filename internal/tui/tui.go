@@ -2,10 +2,8 @@
 package tui
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -18,6 +16,9 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"llm-radar/internal/cache"
+	"llm-radar/internal/checkpoint"
+	"llm-radar/internal/export"
+	"llm-radar/internal/history"
 	"llm-radar/internal/kb"
 	"llm-radar/internal/models"
 	"llm-radar/internal/worker"
@@ -93,10 +94,19 @@ type AppModel struct {
 	width         int
 	height        int
 	workerMsgChan chan tea.Msg
+	cancel        context.CancelFunc
+	workersDone   chan struct{}
+	stopOnce      sync.Once
+	aborted       int
 	mu            sync.RWMutex
 	appName       string
 	version       string
 	cacheExpiry   time.Duration
+	status        string
+	presetModels  []string
+	checkpointDir string
+	resume        *checkpoint.Run
+	checkpoint    *checkpoint.Writer
 }
 
 // NewAppModel creates and initializes a new AppModel.
@@ -108,6 +118,7 @@ func NewAppModel(runCfg models.RunConfig, compiledKB kb.Compiled, appName, versi
 	)
 
 	resultCache := cache.New(runCfg.CachePath, cacheExpiry)
+	resultCache.SetCategoryTTLs(compiledKB.CacheTTLs)
 	if runCfg.UseCache {
 		resultCache.Load()
 	}
@@ -126,10 +137,42 @@ func NewAppModel(runCfg models.RunConfig, compiledKB kb.Compiled, appName, versi
 	}
 }
 
+// SetModelList skips discovery and tests exactly the given models.
+func (m *AppModel) SetModelList(list []string) {
+	m.presetModels = list
+}
+
+// SetCheckpoint keeps a run file in dir until the run completes. When
+// resume is set, its results are shown as already done and only its
+// remaining models are probed.
+func (m *AppModel) SetCheckpoint(dir string, resume *checkpoint.Run) {
+	m.checkpointDir = dir
+	m.resume = resume
+	if resume != nil {
+		// Non-nil even when empty, so discovery is skipped.
+		m.presetModels = append([]string{}, resume.Remaining()...)
+		m.results = append([]models.ModelResult(nil), resume.Results...)
+	}
+}
+
+// RunID returns the ID of the run file, or "" when checkpoints are off.
+func (m *AppModel) RunID() string {
+	if m.checkpoint == nil {
+		return ""
+	}
+	return m.checkpoint.ID()
+}
+
 // Init initializes the Bubble Tea model.
 func (m *AppModel) Init() tea.Cmd {
+	discover := worker.DiscoverModelsCmd(m.kb)
+	if m.presetModels != nil {
+		list := m.presetModels
+		discover = func() tea.Msg { return DiscoveryMsg(list) }
+	}
+
 	return tea.Batch(
-		worker.DiscoverModelsCmd(),
+		discover,
 		waitForWorkerMsg(m.workerMsgChan),
 		tickCmd(),
 	)
@@ -143,11 +186,14 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "q", "ctrl+c":
 			m.quitting = true
+			m.Stop()
 			return m, tea.Quit
 		case "s":
 			if m.done {
-				if err := m.saveResults(); err == nil {
-					// Success
+				if path, err := m.saveResults(); err != nil {
+					m.status = DangerStyle.Render(fmt.Sprintf("\n❌ Erro ao salvar: %v", err))
+				} else {
+					m.status = SuccessStyle.Render(fmt.Sprintf("\n💾 Salvo em %s", path))
 				}
 			}
 		}
@@ -172,36 +218,14 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tickCmd()
 
-	// Handle []string from worker.DiscoverModelsCmd
-	case []string:
-		m.discovering = false
-		m.models = msg
-		m.total = len(msg)
-
-		// Create map of free models for prioritization
-		freeMap := make(map[string]bool)
-		for k := range m.kb.Config.FreeModels {
-			freeMap[k] = true
+	case worker.DiscoveryResult:
+		for _, err := range msg.Warnings {
+			m.status += WarningStyle.Render(fmt.Sprintf("\n⚠️  %v", err))
 		}
-		m.models = worker.PrioritizeModels(m.models, freeMap, "zai-coding-plan/")
-
-		go worker.StartWorkers(m.models, m.runCfg, m.kb, m.cache, m.workerMsgChan, &m.processed)
-		return m, nil
+		return m, m.startRun(msg.Models)
 
 	case DiscoveryMsg:
-		m.discovering = false
-		m.models = msg
-		m.total = len(msg)
-
-		// Create map of free models for prioritization
-		freeMap := make(map[string]bool)
-		for k := range m.kb.Config.FreeModels {
-			freeMap[k] = true
-		}
-		m.models = worker.PrioritizeModels(m.models, freeMap, "zai-coding-plan/")
-
-		go worker.StartWorkers(m.models, m.runCfg, m.kb, m.cache, m.workerMsgChan, &m.processed)
-		return m, nil
+		return m, m.startRun(msg)
 
 	// Handle generic worker start message from worker package
 	case struct{ Model string; Start time.Time }:
@@ -218,57 +242,10 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Handle ModelResult directly from worker package
 	case models.ModelResult:
-		m.mu.Lock()
-		delete(m.activeJobs, msg.Model)
-		m.results = append(m.results, msg)
-		m.mu.Unlock()
-
-		processed := atomic.LoadInt32(&m.processed)
-		cmd := m.progress.SetPercent(float64(processed) / float64(m.total))
-		m.viewport.SetContent(m.renderResultsList())
-		m.viewport.GotoBottom()
-
-		if int(processed) >= m.total {
-			m.done = true
-			m.mu.Lock()
-			m.activeJobs = make(map[string]time.Time)
-			m.mu.Unlock()
-			m.resizeViewport()
-
-			if m.runCfg.UseCache {
-				m.cache.SaveResults(m.results)
-			}
-
-			return m, cmd
-		}
-		return m, tea.Batch(waitForWorkerMsg(m.workerMsgChan), cmd)
+		return m, m.addResult(msg)
 
 	case ItemMsg:
-		res := models.ModelResult(msg)
-		m.mu.Lock()
-		delete(m.activeJobs, res.Model)
-		m.results = append(m.results, res)
-		m.mu.Unlock()
-
-		processed := atomic.LoadInt32(&m.processed)
-		cmd := m.progress.SetPercent(float64(processed) / float64(m.total))
-		m.viewport.SetContent(m.renderResultsList())
-		m.viewport.GotoBottom()
-
-		if int(processed) >= m.total {
-			m.done = true
-			m.mu.Lock()
-			m.activeJobs = make(map[string]time.Time)
-			m.mu.Unlock()
-			m.resizeViewport()
-
-			if m.runCfg.UseCache {
-				m.cache.SaveResults(m.results)
-			}
-
-			return m, cmd
-		}
-		return m, tea.Batch(waitForWorkerMsg(m.workerMsgChan), cmd)
+		return m, m.addResult(models.ModelResult(msg))
 
 	case ErrorMsg:
 		m.err = msg
@@ -283,6 +260,112 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// addResult records a finished model. The run is done once every queued
+// model has a result here; the workers' counter runs ahead of the channel,
+// so it cannot tell whether results sent in a burst were received.
+func (m *AppModel) addResult(res models.ModelResult) tea.Cmd {
+	m.mu.Lock()
+	delete(m.activeJobs, res.Model)
+	m.results = append(m.results, res)
+	received := len(m.results)
+	m.mu.Unlock()
+	m.saveCheckpoint(res)
+
+	cmd := m.progress.SetPercent(float64(received) / float64(m.total))
+	m.viewport.SetContent(m.renderResultsList())
+	m.viewport.GotoBottom()
+
+	if received < m.total {
+		return tea.Batch(waitForWorkerMsg(m.workerMsgChan), cmd)
+	}
+
+	m.done = true
+	m.mu.Lock()
+	m.activeJobs = make(map[string]time.Time)
+	m.mu.Unlock()
+	m.resizeViewport()
+
+	if m.runCfg.UseCache {
+		m.saveCache()
+	}
+	m.finishCheckpoint()
+	m.recordHistory()
+	return cmd
+}
+
+// startRun filters and prioritizes the discovered models and launches the
+// workers. A resumed run's queue is used as saved.
+func (m *AppModel) startRun(modelList []string) tea.Cmd {
+	m.discovering = false
+
+	queue := modelList
+	if m.resume == nil {
+		var err error
+		if queue, err = worker.PrepareQueue(modelList, m.runCfg, m.kb); err != nil {
+			m.err = err
+			return tea.Quit
+		}
+	}
+	if len(queue) == 0 && m.resume == nil {
+		m.err = fmt.Errorf("nenhum modelo para testar")
+		return tea.Quit
+	}
+	m.models = queue
+	m.total = len(m.results) + len(queue)
+	m.processed = int32(len(m.results))
+
+	if err := m.openCheckpoint(); err != nil {
+		m.err = err
+		return tea.Quit
+	}
+	if len(queue) == 0 {
+		// A resumed run with nothing left to probe.
+		m.done = true
+		m.finishCheckpoint()
+		m.recordHistory()
+		m.resizeViewport()
+		m.viewport.SetContent(m.renderResultsList())
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.workersDone = make(chan struct{})
+	go func() {
+		defer close(m.workersDone)
+		m.aborted = worker.StartWorkers(ctx, m.models, m.runCfg, m.kb, m.cache, m.workerMsgChan, &m.processed)
+	}()
+	return nil
+}
+
+// Stop cancels the run, killing running probes, and waits for the workers
+// to exit. Results still in flight are discarded. It is safe to call more
+// than once and before the run starts.
+func (m *AppModel) Stop() {
+	m.stopOnce.Do(func() {
+		if m.cancel == nil {
+			return
+		}
+		m.cancel()
+		for range m.workerMsgChan {
+		}
+		<-m.workersDone
+		if m.checkpoint != nil && !m.done {
+			m.checkpoint.Close()
+		}
+	})
+}
+
+// Interrupted reports how many probes were aborted by Stop and how many
+// models were never probed. Both are zero for a completed run.
+func (m *AppModel) Interrupted() (aborted, skipped int) {
+	if m.done || m.workersDone == nil {
+		return 0, 0
+	}
+	skipped = m.total - int(atomic.LoadInt32(&m.processed)) - m.aborted
+	return m.aborted, skipped
+}
+
 // View renders the UI.
 func (m *AppModel) View() string {
 	if m.err != nil {
@@ -299,6 +382,9 @@ func (m *AppModel) View() string {
 	prog := m.progress.View()
 
 	title := TitleStyle.Render(fmt.Sprintf("🧪 %s v%s", m.appName, m.version))
+	if circuits := m.openCircuits(); len(circuits) > 0 {
+		status += "  " + WarningStyle.Render("🔌 "+strings.Join(circuits, ", "))
+	}
 	header := fmt.Sprintf("\n%s\n\n%s %s\n\n", title, prog, status)
 
 	body := m.viewport.View()
@@ -322,6 +408,9 @@ func (m *AppModel) resizeViewport() {
 	footerHeight := 6
 	if m.done {
 		footerHeight = 4
+		if m.runCfg.Samples > 1 {
+			footerHeight += 2
+		}
 	}
 	vpHeight := m.height - headerHeight - footerHeight
 	if vpHeight < 5 {
@@ -391,27 +480,68 @@ func (m *AppModel) renderResultsList() string {
 				Foreground(ColorSubtle).
 				Render(fmt.Sprintf("(%s)", worker.Truncate(r.Reason, 25)))
 		}
+		if n := len(r.Attempts); n > 1 {
+			line += " " + lipgloss.NewStyle().
+				Foreground(ColorWarning).
+				Render(fmt.Sprintf("↻%d", n))
+		}
+		if r.Waited {
+			line += " " + lipgloss.NewStyle().
+				Foreground(ColorSubtle).
+				Render(fmt.Sprintf("⏳ %s", time.Duration(r.WaitMs)*time.Millisecond))
+		}
+		if r.Skipped {
+			line += " " + lipgloss.NewStyle().
+				Foreground(ColorSubtle).
+				Render(fmt.Sprintf("⏭  (%s)", worker.Truncate(r.Reason, 40)))
+		}
+		if st := r.Samples; st != nil {
+			line += " " + reliabilityStyle(st.SuccessRatio).
+				Render(fmt.Sprintf("✓%d/%d", st.Successes, st.Count))
+			line += " " + lipgloss.NewStyle().
+				Foreground(ColorSubtle).
+				Render(fmt.Sprintf("p50 %s p95 %s",
+					time.Duration(st.P50Ms)*time.Millisecond,
+					time.Duration(st.P95Ms)*time.Millisecond))
+		}
 
 		s.WriteString(line + "\n")
 	}
 	return s.String()
 }
 
-func (m *AppModel) renderFinalReport() string {
+// openCircuits lists the providers whose circuit breaker opened, with the
+// category inferred for their skipped models.
+func (m *AppModel) openCircuits() []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	stats := make(map[string]int)
-	var totalDuration time.Duration
-
+	var circuits []string
+	seen := make(map[string]bool)
 	for _, r := range m.results {
-		stats[r.Category]++
-		totalDuration += time.Duration(r.DurationMs) * time.Millisecond
+		if r.Skipped && strings.HasPrefix(r.Reason, worker.CircuitOpenReason) && !seen[r.Provider] {
+			seen[r.Provider] = true
+			circuits = append(circuits, fmt.Sprintf("%s (%s)", r.Provider, r.Category))
+		}
 	}
+	return circuits
+}
+
+func (m *AppModel) renderFinalReport() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := models.Summarize(m.results)
+	stats := summary.Categories
+	totalDuration := time.Duration(summary.DurationMs) * time.Millisecond
 
 	var s strings.Builder
-	s.WriteString(SuccessStyle.Render(fmt.Sprintf("\n🏁 Concluído - %d modelos testados em %s\n\n",
+	s.WriteString(SuccessStyle.Render(fmt.Sprintf("\n🏁 Concluído - %d modelos testados em %s",
 		m.total, totalDuration.Round(time.Second))))
+	if n := stats[models.CategorySkipped]; n > 0 && m.runCfg.Deadline > 0 {
+		s.WriteString(WarningStyle.Render(fmt.Sprintf(" (prazo de %s esgotado: %d não testados)", m.runCfg.Deadline, n)))
+	}
+	s.WriteString("\n\n")
 
 	for _, cat := range models.AllCategories() {
 		if count := stats[cat]; count > 0 {
@@ -423,41 +553,164 @@ func (m *AppModel) renderFinalReport() string {
 
 	s.WriteString("\n\n")
 
-	usable := stats[models.CategoryFree] + stats[models.CategoryFreeLimited] +
-		stats[models.CategoryPaid] + stats[models.CategoryAvailable]
+	usable := summary.Usable
 
 	s.WriteString(SuccessStyle.Render(fmt.Sprintf("✨ %d modelos utilizáveis ", usable)))
-	s.WriteString(lipgloss.NewStyle().Foreground(ColorSubtle).
-		Render(fmt.Sprintf("(%d%%)\n", usable*100/m.total)))
+	if m.total > 0 {
+		s.WriteString(lipgloss.NewStyle().Foreground(ColorSubtle).
+			Render(fmt.Sprintf("(%d%%)", usable*100/m.total)))
+	}
+	s.WriteString("\n")
+
+	if m.runCfg.Samples > 1 {
+		s.WriteString(m.renderReliability())
+	}
 
 	s.WriteString(lipgloss.NewStyle().Foreground(ColorSubtle).
 		Render("\n(q: sair | s: salvar resultados)"))
+	s.WriteString(m.status)
 
 	return s.String()
 }
 
-func (m *AppModel) saveResults() error {
-	timestamp := time.Now().Format("20060102-150405")
-	filename := fmt.Sprintf("llm-radar-%s.json", timestamp)
+// renderReliability summarizes multi-sample runs: how many models answered
+// every sample, some of them, or none, naming the least reliable ones.
+// Callers hold m.mu.
+func (m *AppModel) renderReliability() string {
+	var stable, none int
+	var unstable []models.ModelResult
+	for _, r := range m.results {
+		st := r.Samples
+		if st == nil {
+			continue
+		}
+		switch {
+		case st.Successes == st.Count:
+			stable++
+		case st.Successes == 0:
+			none++
+		default:
+			unstable = append(unstable, r)
+		}
+	}
+	sort.SliceStable(unstable, func(i, j int) bool {
+		return unstable[i].Samples.SuccessRatio < unstable[j].Samples.SuccessRatio
+	})
 
-	dir := filepath.Join(os.Getenv("HOME"), ".config", "opencode", "results")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("📈 %d amostras/modelo: ", m.runCfg.Samples))
+	s.WriteString(reliabilityStyle(1).Render(fmt.Sprintf("%d estáveis", stable)) + " · ")
+	s.WriteString(reliabilityStyle(0.5).Render(fmt.Sprintf("%d instáveis", len(unstable))) + " · ")
+	s.WriteString(reliabilityStyle(0).Render(fmt.Sprintf("%d sem resposta", none)) + "\n")
+
+	if len(unstable) > 0 {
+		const maxListed = 5
+		var names []string
+		for i, r := range unstable {
+			if i == maxListed {
+				names = append(names, fmt.Sprintf("+%d", len(unstable)-maxListed))
+				break
+			}
+			names = append(names, fmt.Sprintf("%s (%.0f%%)", r.Model, r.Samples.SuccessRatio*100))
+		}
+		s.WriteString(lipgloss.NewStyle().Foreground(ColorSubtle).
+			Render("   ⚠️  "+strings.Join(names, ", ")) + "\n")
+	}
+	return s.String()
+}
+
+// reliabilityStyle colors a sample success ratio: all, some or none.
+func reliabilityStyle(ratio float64) lipgloss.Style {
+	switch {
+	case ratio >= 1:
+		return lipgloss.NewStyle().Foreground(ColorSuccess)
+	case ratio > 0:
+		return lipgloss.NewStyle().Foreground(ColorWarning)
+	}
+	return lipgloss.NewStyle().Foreground(ColorDanger)
+}
+
+// saveResults exports the results using the configured format and path,
+// returning the path written.
+func (m *AppModel) saveResults() (string, error) {
+	format := m.runCfg.ExportFormat
+	if format == "" {
+		format = export.FormatJSON
 	}
+	path := export.ResolvePath(m.runCfg.ExportPath, format, time.Now())
 
-	path := filepath.Join(dir, filename)
+	doc := export.NewDocument(m.appName, m.version, m.Results())
+	return path, export.WriteFile(path, format, doc)
+}
+
+// openCheckpoint starts the run file, or reopens it when resuming.
+func (m *AppModel) openCheckpoint() error {
+	if m.checkpointDir == "" {
+		return nil
+	}
 
-	data, err := json.MarshalIndent(map[string]interface{}{
-		"timestamp": timestamp,
-		"version":   m.version,
-		"total":     m.total,
-		"results":   m.results,
-	}, "", "  ")
-	if err != nil {
+	var err error
+	if m.resume != nil {
+		m.checkpoint, err = checkpoint.Reopen(m.checkpointDir, *m.resume)
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	now := time.Now()
+	m.checkpoint, err = checkpoint.Create(m.checkpointDir, checkpoint.Header{
+		RunID:   checkpoint.NewID(now),
+		Started: now,
+		Prompt:  m.runCfg.Prompt,
+		Probe:   m.runCfg.Probe,
+		KBHash:  m.kb.Fingerprint(),
+		Models:  m.models,
+	})
+	return err
+}
+
+func (m *AppModel) saveCheckpoint(res models.ModelResult) {
+	if m.checkpoint == nil {
+		return
+	}
+	if err := m.checkpoint.Append(res); err != nil {
+		m.status = DangerStyle.Render(fmt.Sprintf("\n❌ %v", err))
+	}
+}
+
+// finishCheckpoint deletes the run file once every model has a result.
+func (m *AppModel) finishCheckpoint() {
+	if m.checkpoint == nil {
+		return
+	}
+	if err := m.checkpoint.Finish(); err != nil {
+		m.status = DangerStyle.Render(fmt.Sprintf("\n❌ %v", err))
+	}
+}
+
+// recordHistory adds the finished run to the history read by the KB
+// priority rules.
+func (m *AppModel) recordHistory() {
+	if m.runCfg.HistoryPath == "" {
+		return
+	}
+	if err := history.Update(m.runCfg.HistoryPath, m.Results()); err != nil {
+		m.status = DangerStyle.Render(fmt.Sprintf("\n❌ %v", err))
+	}
+}
+
+func (m *AppModel) saveCache() error {
+	return m.cache.SaveResults(m.results, worker.CacheKeyFunc(m.runCfg, m.kb))
+}
+
+// Results returns a copy of the results collected so far.
+func (m *AppModel) Results() []models.ModelResult {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]models.ModelResult(nil), m.results...)
+}
+
+// CacheStats returns the cache lookup counters for the run.
+func (m *AppModel) CacheStats() cache.Stats {
+	return m.cache.Stats()
 }
 
 // ============================================================================
@@ -466,10 +719,10 @@ func (m *AppModel) saveResults() error {
 
 // GetStyleForCategory returns the appropriate lipgloss style for a category.
 func GetStyleForCategory(cat string) lipgloss.Style {
-	switch cat {
-	case models.CategoryFree, models.CategoryFreeLimited, models.CategoryPaid, models.CategoryAvailable:
+	switch models.CategorySeverity(cat) {
+	case models.SeveritySuccess:
 		return SuccessStyle
-	case models.CategoryTimeout, models.CategoryNotFound, models.CategoryRateLimited:
+	case models.SeverityWarning:
 		return WarningStyle
 	default:
 		return DangerStyle
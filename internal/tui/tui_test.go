@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
+)
+
+// installFakeCLI puts an executable shell script named name first and alone
+// on PATH for the duration of the test.
+func installFakeCLI(t *testing.T, name, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+// runToCompletion starts a run over modelList and feeds the worker messages
+// to Update, as the Bubble Tea loop would, until the model reports done.
+func runToCompletion(t *testing.T, cfg models.RunConfig, modelList []string) *AppModel {
+	t.Helper()
+	compiled, err := kb.Compile(kb.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.HistoryPath = filepath.Join(t.TempDir(), "history.json")
+
+	m := NewAppModel(cfg, compiled, "llm-radar", "test", time.Hour)
+	t.Cleanup(m.Stop)
+	if cmd := m.startRun(modelList); cmd != nil {
+		t.Fatalf("startRun failed: %v", m.err)
+	}
+
+	timeout := time.After(30 * time.Second)
+	for !m.done {
+		msg := make(chan any, 1)
+		go func() { msg <- waitForWorkerMsg(m.workerMsgChan)() }()
+		select {
+		case got := <-msg:
+			if got == nil {
+				t.Fatalf("Worker channel closed with %d/%d results", len(m.Results()), m.total)
+			}
+			m.Update(got)
+		case <-timeout:
+			t.Fatalf("Run did not complete, got %d/%d results", len(m.Results()), m.total)
+		}
+	}
+	return m
+}
+
+func modelNames(n int) []string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = fmt.Sprintf("acme/m%02d", i)
+	}
+	return list
+}
+
+func TestRunKeepsDeadlineSkips(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	installFakeCLI(t, "opencode", "exec "+sleep+" 30\n")
+
+	cfg := models.RunConfig{Prompt: "p", Timeout: 30 * time.Second, Concurrency: 2, MaxOutputKB: 64,
+		Deadline: 300 * time.Millisecond}
+	m := runToCompletion(t, cfg, modelNames(40))
+
+	results := m.Results()
+	if len(results) != 40 {
+		t.Fatalf("Expected every drained model in the results, got %d/40", len(results))
+	}
+	for _, res := range results {
+		if res.Category != models.CategorySkipped {
+			t.Errorf("%s: expected SKIPPED, got %s", res.Model, res.Category)
+		}
+	}
+}
+
+func TestRunKeepsCircuitOpenSkips(t *testing.T) {
+	installFakeCLI(t, "opencode", "echo 'Error: 401 unauthorized'\nexit 1\n")
+
+	cfg := models.RunConfig{Prompt: "p", Timeout: 5 * time.Second, Concurrency: 1, MaxOutputKB: 64, CircuitBreaker: 2}
+	m := runToCompletion(t, cfg, modelNames(40))
+
+	var skipped int
+	for _, res := range m.Results() {
+		if res.Skipped {
+			skipped++
+		}
+	}
+	if n := len(m.Results()); n != 40 || skipped != 38 {
+		t.Errorf("Expected 40 results with 38 skipped by the breaker, got %d with %d skipped", n, skipped)
+	}
+}
@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
+)
+
+// CommandProber probes models by running a command template such as
+// "llm -m {name} {prompt}".
+type CommandProber struct {
+	Template string
+}
+
+// Probe expands the template and runs it in its own process group.
+func (p CommandProber) Probe(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+	args := ExpandTemplate(p.Template, model, cfg.Prompt)
+	if len(args) == 0 {
+		return Response{ExitCode: 1}, fmt.Errorf("template de comando vazio")
+	}
+
+	start := time.Now()
+	out, code, err := ExecuteCommandSecure(ctx, args[0], args[1:]...)
+	return Response{Output: out, ExitCode: code, Duration: time.Since(start)}, err
+}
+
+// ExpandTemplate splits a command template on whitespace and replaces the
+// placeholders in each argument. No shell is involved, so a prompt with
+// spaces stays a single argument.
+func ExpandTemplate(template, model, prompt string) []string {
+	provider, name, found := strings.Cut(model, "/")
+	if !found {
+		provider, name = "", model
+	}
+	r := strings.NewReplacer(
+		"{model}", model,
+		"{provider}", provider,
+		"{name}", name,
+		"{prompt}", prompt,
+	)
+
+	fields := strings.Fields(template)
+	for i, f := range fields {
+		fields[i] = r.Replace(f)
+	}
+	return fields
+}
+
+// ============================================================================
+// DISCOVERY
+// ============================================================================
+
+// DiscoverAll lists the models reported by `opencode models` plus those of
+// every provider whose backend defines a discovery command. When template
+// discovery finds models, a failing opencode discovery is not an error, so
+// teams without opencode can still run. A failing discovery command only
+// skips its provider; the error is passed to warn, which may be nil.
+func DiscoverAll(compiledKB kb.Compiled, warn func(error)) ([]string, error) {
+	var discovered []string
+	for _, provider := range sortedProviders(compiledKB) {
+		name, b := compiledKB.BackendFor(provider)
+		if b.Discover == "" {
+			continue
+		}
+		list, err := discoverWithTemplate(provider, b.Discover, compiledKB, name)
+		if err != nil {
+			if warn != nil {
+				warn(err)
+			}
+			continue
+		}
+		discovered = append(discovered, list...)
+	}
+
+	opencodeModels, err := DiscoverModels()
+	if err != nil && len(discovered) == 0 {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var all []string
+	for _, m := range append(opencodeModels, discovered...) {
+		if !seen[m] {
+			seen[m] = true
+			all = append(all, m)
+		}
+	}
+	sort.Strings(all)
+	return all, nil
+}
+
+// discoverWithTemplate runs a backend's discovery command and prefixes each
+// model name with the provider.
+func discoverWithTemplate(provider, template string, compiledKB kb.Compiled, backend string) ([]string, error) {
+	// Only {provider} is meaningful here; {name} and {prompt} expand to nothing.
+	args := ExpandTemplate(template, provider+"/", "")
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	out, code, err := ExecuteCommandSecure(ctx, args[0], args[1:]...)
+	if err != nil || code != 0 {
+		return nil, fmt.Errorf("falha ao descobrir modelos de %s (%s): exit %d %v", provider, template, code, err)
+	}
+
+	re := compiledKB.DiscoverRegex(backend)
+	var list []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var name string
+		if re != nil {
+			m := re.FindStringSubmatch(line)
+			switch {
+			case m == nil:
+				continue
+			case len(m) > 1:
+				name = m[1]
+			default:
+				name = m[0]
+			}
+		} else {
+			// Without a regex the first column is the name; an all-caps
+			// first column is a table header such as `ollama list` prints.
+			name = strings.Fields(line)[0]
+			if isHeaderField(name) {
+				continue
+			}
+		}
+
+		if name != "" {
+			list = append(list, provider+"/"+name)
+		}
+	}
+	return list, nil
+}
+
+// isHeaderField reports whether f looks like a column title ("NAME", "MODEL_ID").
+func isHeaderField(f string) bool {
+	return f == strings.ToUpper(f) && f != strings.ToLower(f)
+}
+
+// sortedProviders returns the providers explicitly mapped to a backend.
+// The "*" entry is skipped since it names no provider to discover.
+func sortedProviders(compiledKB kb.Compiled) []string {
+	var providers []string
+	for p := range compiledKB.Config.ProviderBackends {
+		if p != kb.DefaultBackendProvider {
+			providers = append(providers, p)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
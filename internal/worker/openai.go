@@ -0,0 +1,179 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
+)
+
+// maxResponseBytes caps how much of an HTTP response body is read.
+const maxResponseBytes = 1 << 20
+
+// quotaErrorRe recognizes quota and billing errors in JSON error bodies,
+// which some providers return with 403 instead of 402. It is only applied
+// to 403: other statuses, such as 401 or 429, often link to billing pages.
+var quotaErrorRe = regexp.MustCompile(`(?i)(insufficient.?quota|quota|credit|billing|payment)`)
+
+// OpenAIProber sends a minimal chat-completions request to an
+// OpenAI-compatible API.
+type OpenAIProber struct {
+	BaseURL   string
+	APIKeyEnv string
+	Client    *http.Client
+}
+
+// NewOpenAIProber builds an HTTP backend from its KB definition.
+func NewOpenAIProber(b kb.BackendConfig) *OpenAIProber {
+	return &OpenAIProber{
+		BaseURL:   strings.TrimRight(b.BaseURL, "/"),
+		APIKeyEnv: b.APIKeyEnv,
+		Client:    &http.Client{},
+	}
+}
+
+type chatRequest struct {
+	Model     string        `json:"model"`
+	Messages  []chatMessage `json:"messages"`
+	MaxTokens int           `json:"max_tokens"`
+	Stream    bool          `json:"stream"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+type apiError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+	} `json:"error"`
+}
+
+// Probe posts the prompt to /chat/completions. The provider prefix is
+// stripped from the model name ("groq/llama-3.3-70b" is sent as
+// "llama-3.3-70b").
+func (p *OpenAIProber) Probe(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+	start := time.Now()
+
+	var apiKey string
+	if p.APIKeyEnv != "" {
+		if apiKey = os.Getenv(p.APIKeyEnv); apiKey == "" {
+			reason := fmt.Sprintf("%s não definida", p.APIKeyEnv)
+			return Response{Output: reason, ExitCode: 1, Category: models.CategoryAuthFailed, Reason: reason}, nil
+		}
+	}
+
+	_, name, _ := strings.Cut(model, "/")
+	body, err := json.Marshal(chatRequest{
+		Model:     name,
+		Messages:  []chatMessage{{Role: "user", Content: cfg.Prompt}},
+		MaxTokens: 32,
+	})
+	if err != nil {
+		return Response{ExitCode: 1}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return Response{ExitCode: 1}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		out := Response{Output: err.Error(), ExitCode: 1, Duration: time.Since(start)}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			out.ExitCode = 124
+			return out, ctxErr
+		}
+		return out, nil
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	duration := time.Since(start)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Response{ExitCode: 124, Duration: duration}, ctx.Err()
+		}
+		return Response{Output: err.Error(), ExitCode: 1, Duration: duration}, nil
+	}
+
+	if resp.StatusCode/100 == 2 {
+		var chat chatResponse
+		if err := json.Unmarshal(data, &chat); err != nil || len(chat.Choices) == 0 {
+			return Response{Output: string(data), ExitCode: 1, Duration: duration}, nil
+		}
+		return Response{Output: chat.Choices[0].Message.Content, Duration: duration}, nil
+	}
+
+	out := Response{Output: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, data), ExitCode: 1, Duration: duration}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		// Exposed for the KB retry hint patterns.
+		out.Output += "\nRetry-After: " + retryAfter
+	}
+	out.Category, out.Reason = classifyHTTPError(resp.StatusCode, data)
+	return out, nil
+}
+
+// classifyHTTPError maps an HTTP error status and its JSON body to a
+// category. Unknown statuses return an empty category so the KB regexes
+// decide.
+func classifyHTTPError(status int, body []byte) (string, string) {
+	var apiErr apiError
+	_ = json.Unmarshal(body, &apiErr)
+	detail := strings.TrimSpace(fmt.Sprintf("%s %s %v", apiErr.Error.Type, apiErr.Error.Message, codeString(apiErr.Error.Code)))
+
+	reason := fmt.Sprintf("HTTP %d", status)
+	if apiErr.Error.Message != "" {
+		reason += ": " + Truncate(apiErr.Error.Message, 80)
+	}
+
+	switch {
+	case status == http.StatusTooManyRequests:
+		// OpenAI-compatible APIs flag exhausted credits with this error
+		// type or code; any other 429 is a rate limit.
+		if apiErr.Error.Type == "insufficient_quota" || codeString(apiErr.Error.Code) == "insufficient_quota" {
+			return models.CategoryNoQuota, reason
+		}
+		return models.CategoryRateLimited, reason
+	case status == http.StatusPaymentRequired:
+		return models.CategoryNoQuota, reason
+	case status == http.StatusForbidden && quotaErrorRe.MatchString(detail):
+		return models.CategoryNoQuota, reason
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return models.CategoryAuthFailed, reason
+	case status == http.StatusNotFound || strings.Contains(detail, "model_not_found"):
+		return models.CategoryNotFound, reason
+	}
+	return "", ""
+}
+
+func codeString(code any) string {
+	if code == nil {
+		return ""
+	}
+	return fmt.Sprint(code)
+}
@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
+)
+
+// ============================================================================
+// PROBER INTERFACE
+// ============================================================================
+
+// Response is the raw outcome of sending the prompt to a model once.
+// ExitCode follows CLI conventions: 0 on success and 124 on timeout.
+type Response struct {
+	Output   string
+	ExitCode int
+	Duration time.Duration
+	// Category is set by backends that can tell the failure category from
+	// the protocol itself (e.g. an HTTP status); Reason then explains it.
+	// Output matching is skipped for such responses.
+	Category string
+	Reason   string
+}
+
+// Prober sends the run prompt to a model and reports what came back.
+// Implementations must honor ctx cancellation; err is context.DeadlineExceeded
+// when the probe timed out.
+type Prober interface {
+	Probe(ctx context.Context, model string, cfg models.RunConfig) (Response, error)
+}
+
+// ProberFunc adapts a function to the Prober interface.
+type ProberFunc func(ctx context.Context, model string, cfg models.RunConfig) (Response, error)
+
+// Probe calls f(ctx, model, cfg).
+func (f ProberFunc) Probe(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+	return f(ctx, model, cfg)
+}
+
+// ============================================================================
+// IMPLEMENTATIONS
+// ============================================================================
+
+// OpencodeProber probes models with `opencode run --model <model> <prompt>`.
+type OpencodeProber struct{}
+
+// Probe runs the opencode CLI in its own process group.
+func (OpencodeProber) Probe(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+	start := time.Now()
+	out, code, err := ExecuteCommandSecure(ctx, "opencode", "run", "--model", model, cfg.Prompt)
+	return Response{Output: out, ExitCode: code, Duration: time.Since(start)}, err
+}
+
+// NewProber builds a backend from its KB definition.
+func NewProber(b kb.BackendConfig) Prober {
+	switch b.Type {
+	case kb.BackendOpencode:
+		return OpencodeProber{}
+	case kb.BackendOpenAI:
+		return NewOpenAIProber(b)
+	case kb.BackendCommand:
+		return CommandProber{Template: b.Command}
+	}
+	// Types are validated when the KB is compiled.
+	return OpencodeProber{}
+}
+
+// ProviderRouter dispatches each probe to the backend configured for the
+// model's provider.
+type ProviderRouter struct {
+	kb       kb.Compiled
+	backends map[string]Prober
+}
+
+// NewProviderRouter builds one prober per backend referenced by the KB.
+// When cfg.Serve is set, every opencode backend shares one ServeProber.
+func NewProviderRouter(compiledKB kb.Compiled, cfg models.RunConfig) *ProviderRouter {
+	opencode := Prober(OpencodeProber{})
+	if cfg.Serve != "" {
+		opencode = NewServeProber(cfg.Serve)
+	}
+
+	r := &ProviderRouter{
+		kb:       compiledKB,
+		backends: map[string]Prober{kb.BackendOpencode: opencode},
+	}
+	for name, b := range compiledKB.Config.Backends {
+		if b.Type == kb.BackendOpencode {
+			r.backends[name] = opencode
+			continue
+		}
+		r.backends[name] = NewProber(b)
+	}
+	return r
+}
+
+// Close releases backends that hold resources, such as a started
+// `opencode serve` process.
+func (r *ProviderRouter) Close() error {
+	closed := make(map[io.Closer]bool)
+	var errs []error
+	for _, p := range r.backends {
+		if c, ok := p.(io.Closer); ok && !closed[c] {
+			closed[c] = true
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Probe forwards to the provider's backend.
+func (r *ProviderRouter) Probe(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+	name, _ := r.kb.BackendFor(ExtractProvider(model))
+	return r.backends[name].Probe(ctx, model, cfg)
+}
@@ -0,0 +1,325 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
+)
+
+// ============================================================================
+// SCHEDULER
+// ============================================================================
+
+// Scheduler hands queued models to workers while respecting per-provider
+// in-flight and requests-per-minute limits. Models whose provider is at
+// capacity are skipped, so other providers keep making progress.
+type Scheduler struct {
+	mu       sync.Mutex
+	queue    []queued
+	delays   map[string]time.Duration
+	inFlight map[string]int
+	running  int
+	stopped  bool
+	limits   map[string]kb.ProviderLimit
+	buckets  map[string]*tokenBucket
+	// breaker is the circuit breaker threshold (0 disables it); failures
+	// counts consecutive auth/quota verdicts per provider.
+	breaker  int
+	failures map[string]int
+	open     map[string]string
+	// changed is closed and replaced whenever capacity or the queue changes.
+	changed chan struct{}
+}
+
+// queued is a model waiting to be probed, not before notBefore.
+type queued struct {
+	model     string
+	notBefore time.Time
+}
+
+// NewScheduler queues models in order under the given provider limits.
+func NewScheduler(modelList []string, limits map[string]kb.ProviderLimit) *Scheduler {
+	s := &Scheduler{
+		delays:   make(map[string]time.Duration),
+		inFlight: make(map[string]int),
+		limits:   limits,
+		buckets:  make(map[string]*tokenBucket),
+		failures: make(map[string]int),
+		open:     make(map[string]string),
+		changed:  make(chan struct{}),
+	}
+	for _, m := range modelList {
+		s.queue = append(s.queue, queued{model: m})
+	}
+	for provider, l := range limits {
+		if l.RPM > 0 {
+			s.buckets[provider] = newTokenBucket(l.RPM, l.Burst)
+		}
+	}
+	return s
+}
+
+// Next blocks until a queued model's provider has capacity and returns it.
+// It returns false once the queue is empty and no probe is running (a
+// running probe may still requeue its model), or after Stop.
+func (s *Scheduler) Next() (string, bool) {
+	for {
+		s.mu.Lock()
+		if s.stopped || (len(s.queue) == 0 && s.running == 0) {
+			s.mu.Unlock()
+			return "", false
+		}
+
+		model, wait := s.pick(time.Now())
+		if model != "" {
+			s.mu.Unlock()
+			return model, true
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-changed:
+			case <-timer.C:
+			}
+			timer.Stop()
+		} else {
+			<-changed
+		}
+	}
+}
+
+// pick takes the first queued model that is due and whose provider has
+// capacity. When none qualifies, it returns how long until a requeued model
+// is due or a rate-limited provider gets a token (0 if every candidate waits
+// on in-flight probes). Callers hold s.mu.
+func (s *Scheduler) pick(now time.Time) (string, time.Duration) {
+	var wait time.Duration
+	earliest := func(d time.Duration) {
+		if wait == 0 || d < wait {
+			wait = d
+		}
+	}
+
+	for i, q := range s.queue {
+		model := q.model
+		if now.Before(q.notBefore) {
+			earliest(q.notBefore.Sub(now))
+			continue
+		}
+
+		provider := ExtractProvider(model)
+		if l, ok := s.limits[provider]; ok && l.MaxInFlight > 0 && s.inFlight[provider] >= l.MaxInFlight {
+			continue
+		}
+		if b, ok := s.buckets[provider]; ok {
+			if d := b.take(now); d > 0 {
+				earliest(d)
+				continue
+			}
+		}
+
+		s.queue = append(s.queue[:i], s.queue[i+1:]...)
+		s.inFlight[provider]++
+		s.running++
+		return model, 0
+	}
+	return "", wait
+}
+
+// Done releases the capacity held by a model returned by Next.
+func (s *Scheduler) Done(model string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight[ExtractProvider(model)]--
+	s.running--
+	s.notify()
+}
+
+// RequeueAfter puts a model back at the end of the queue, to be handed out
+// no earlier than delay from now. The delay is remembered for Delay.
+func (s *Scheduler) RequeueAfter(model string, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.push(model, delay)
+	s.delays[model] += delay
+}
+
+// Later puts a model back at the end of the queue to be probed again, no
+// earlier than delay from now. Unlike RequeueAfter, the delay is not a
+// rate-limit wait and is not reported by Delay.
+func (s *Scheduler) Later(model string, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.push(model, delay)
+}
+
+// push queues a model and wakes waiting callers. Callers hold s.mu.
+func (s *Scheduler) push(model string, delay time.Duration) {
+	s.queue = append(s.queue, queued{model: model, notBefore: time.Now().Add(delay)})
+	s.notify()
+}
+
+// Delay reports the total requeue delay of a model and whether it was
+// requeued at all.
+func (s *Scheduler) Delay(model string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.delays[model]
+	return d, ok
+}
+
+// SetCircuitBreaker opens a provider's circuit after threshold consecutive
+// auth or quota failures. A threshold of 0 disables the breaker.
+func (s *Scheduler) SetCircuitBreaker(threshold int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breaker = threshold
+}
+
+// Record feeds a model's verdict to the circuit breaker. When it opens the
+// provider's circuit, the provider's queued models are removed and
+// returned; they are expected to fail the same way. Later verdicts of
+// models already running do not reopen it.
+func (s *Scheduler) Record(model, category string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	provider := ExtractProvider(model)
+	if s.breaker <= 0 || s.open[provider] != "" {
+		return nil
+	}
+	if category != models.CategoryAuthFailed && category != models.CategoryNoQuota {
+		s.failures[provider] = 0
+		return nil
+	}
+
+	s.failures[provider]++
+	if s.failures[provider] < s.breaker {
+		return nil
+	}
+	s.open[provider] = category
+
+	var skipped []string
+	kept := s.queue[:0]
+	for _, q := range s.queue {
+		if ExtractProvider(q.model) == provider {
+			skipped = append(skipped, q.model)
+		} else {
+			kept = append(kept, q)
+		}
+	}
+	s.queue = kept
+	s.notify()
+	return skipped
+}
+
+// Acquire blocks until the provider's RPM limit allows another request and
+// takes its token, reporting false if ctx ends first. Next already takes
+// the token of a model's first probe; Acquire covers its retries. It is
+// safe to call on a nil Scheduler.
+func (s *Scheduler) Acquire(ctx context.Context, provider string) bool {
+	if s == nil {
+		return true
+	}
+	for {
+		s.mu.Lock()
+		b, ok := s.buckets[provider]
+		var wait time.Duration
+		if ok {
+			wait = b.take(time.Now())
+		}
+		s.mu.Unlock()
+
+		if wait == 0 {
+			return true
+		}
+		if !sleepCtx(ctx, wait) {
+			return false
+		}
+	}
+}
+
+// CircuitOpen reports whether the circuit of the model's provider is open.
+func (s *Scheduler) CircuitOpen(model string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.open[ExtractProvider(model)] != ""
+}
+
+// Stop makes Next return false from now on, waking any blocked callers.
+// Queued models stay queued and are returned by Drain.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	s.notify()
+}
+
+// Drain removes and returns the queued models not yet handed out.
+func (s *Scheduler) Drain() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	drained := make([]string, len(s.queue))
+	for i, q := range s.queue {
+		drained[i] = q.model
+	}
+	s.queue = nil
+	s.notify()
+	return drained
+}
+
+func (s *Scheduler) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// ============================================================================
+// TOKEN BUCKET
+// ============================================================================
+
+// tokenBucket refills rpm tokens per minute up to burst tokens.
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	perSec   float64
+	last     time.Time
+}
+
+func newTokenBucket(rpm, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{
+		tokens:   float64(burst),
+		capacity: float64(burst),
+		perSec:   float64(rpm) / 60,
+		last:     time.Now(),
+	}
+}
+
+// take consumes a token and returns 0, or returns how long until one is
+// available without consuming anything.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.tokens += now.Sub(b.last).Seconds() * b.perSec
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	wait := time.Duration((1 - b.tokens) / b.perSec * float64(time.Second))
+	if wait < time.Millisecond {
+		wait = time.Millisecond
+	}
+	return wait
+}
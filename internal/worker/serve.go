@@ -0,0 +1,273 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"llm-radar/internal/models"
+)
+
+// ServeAuto makes ServeProber start its own `opencode serve` instance.
+const ServeAuto = "auto"
+
+// serveStartTimeout bounds how long a started server may take to answer.
+const serveStartTimeout = 20 * time.Second
+
+// ServeProber sends every probe through a single `opencode serve` HTTP
+// server instead of forking the CLI per model. It either attaches to a
+// running server or starts one on first use; Close stops a started server.
+type ServeProber struct {
+	// Target is ServeAuto or the base URL of a running server.
+	Target string
+	Client *http.Client
+
+	once    sync.Once
+	baseURL string
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	err     error
+}
+
+// NewServeProber returns a prober for target (ServeAuto or a URL).
+func NewServeProber(target string) *ServeProber {
+	return &ServeProber{Target: target, Client: &http.Client{}}
+}
+
+type serveSession struct {
+	ID string `json:"id"`
+}
+
+type servePart struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type servePrompt struct {
+	ProviderID string      `json:"providerID"`
+	ModelID    string      `json:"modelID"`
+	Parts      []servePart `json:"parts"`
+}
+
+type serveMessage struct {
+	Info struct {
+		Error *struct {
+			Name string `json:"name"`
+			Data struct {
+				Message string `json:"message"`
+			} `json:"data"`
+		} `json:"error"`
+	} `json:"info"`
+	Parts []servePart `json:"parts"`
+}
+
+// Probe creates a session, sends the prompt and deletes the session.
+func (p *ServeProber) Probe(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+	start := time.Now()
+	if err := p.ensureServer(); err != nil {
+		return Response{Output: err.Error(), ExitCode: 1, Duration: time.Since(start)}, nil
+	}
+
+	var session serveSession
+	if err := p.post(ctx, "/session", map[string]string{"title": "llm-radar " + model}, &session); err != nil {
+		return p.failure(ctx, start, err)
+	}
+	defer p.cleanup(ctx, session.ID)
+
+	providerID, modelID, _ := strings.Cut(model, "/")
+	var msg serveMessage
+	err := p.post(ctx, "/session/"+session.ID+"/message", servePrompt{
+		ProviderID: providerID,
+		ModelID:    modelID,
+		Parts:      []servePart{{Type: "text", Text: cfg.Prompt}},
+	}, &msg)
+	if err != nil {
+		return p.failure(ctx, start, err)
+	}
+
+	duration := time.Since(start)
+	if e := msg.Info.Error; e != nil {
+		resp := Response{Output: e.Name + ": " + e.Data.Message, ExitCode: 1, Duration: duration}
+		if e.Name == "ProviderAuthError" {
+			resp.Category, resp.Reason = models.CategoryAuthFailed, "API key inválida"
+		}
+		return resp, nil
+	}
+
+	var out strings.Builder
+	for _, part := range msg.Parts {
+		if part.Type == "text" {
+			out.WriteString(part.Text)
+		}
+	}
+	return Response{Output: out.String(), Duration: duration}, nil
+}
+
+// Close stops the server if this prober started it.
+func (p *ServeProber) Close() error {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return nil
+	}
+
+	signalGroup(p.cmd, syscall.SIGTERM)
+	select {
+	case <-p.exited:
+	case <-time.After(3 * time.Second):
+		signalGroup(p.cmd, syscall.SIGKILL)
+		<-p.exited
+	}
+	return nil
+}
+
+// ============================================================================
+// SERVER LIFECYCLE
+// ============================================================================
+
+func (p *ServeProber) ensureServer() error {
+	p.once.Do(func() {
+		if p.Target != ServeAuto {
+			p.baseURL = strings.TrimRight(p.Target, "/")
+			return
+		}
+		p.err = p.start()
+	})
+	return p.err
+}
+
+// start launches `opencode serve` on a free local port and waits until it
+// accepts requests.
+func (p *ServeProber) start() error {
+	port, err := freePort()
+	if err != nil {
+		return fmt.Errorf("falha ao reservar porta: %w", err)
+	}
+
+	cmd := exec.Command("opencode", "serve", "--hostname", "127.0.0.1", "--port", strconv.Itoa(port))
+	if runtime.GOOS != "windows" {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+	var logs bytes.Buffer
+	cmd.Stdout = &logs
+	cmd.Stderr = &logs
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("falha ao iniciar opencode serve: %w", err)
+	}
+	p.cmd = cmd
+	p.exited = make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(p.exited)
+	}()
+
+	p.baseURL = fmt.Sprintf("http://127.0.0.1:%d", port)
+	deadline := time.Now().Add(serveStartTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-p.exited:
+			return fmt.Errorf("opencode serve encerrou ao iniciar: %s", strings.TrimSpace(logs.String()))
+		default:
+		}
+
+		if conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 200*time.Millisecond); err == nil {
+			conn.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	p.Close()
+	return fmt.Errorf("opencode serve não respondeu em %s", serveStartTimeout)
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func signalGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	if runtime.GOOS != "windows" {
+		syscall.Kill(-cmd.Process.Pid, sig)
+		return
+	}
+	cmd.Process.Kill()
+}
+
+// ============================================================================
+// HTTP HELPERS
+// ============================================================================
+
+func (p *ServeProber) post(ctx context.Context, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, respBody)
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// cleanup aborts a timed-out session and deletes it. It uses its own short
+// context since the probe context may already be done.
+func (p *ServeProber) cleanup(ctx context.Context, sessionID string) {
+	reqCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if ctx.Err() != nil {
+		p.request(reqCtx, http.MethodPost, "/session/"+sessionID+"/abort")
+	}
+	p.request(reqCtx, http.MethodDelete, "/session/"+sessionID)
+}
+
+func (p *ServeProber) request(ctx context.Context, method, path string) {
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, nil)
+	if err != nil {
+		return
+	}
+	if resp, err := p.Client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func (p *ServeProber) failure(ctx context.Context, start time.Time, err error) (Response, error) {
+	resp := Response{Output: err.Error(), ExitCode: 1, Duration: time.Since(start)}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		resp.ExitCode = 124
+		return resp, ctx.Err()
+	}
+	return resp, nil
+}
@@ -2,10 +2,14 @@
 package worker
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
 	"os/exec"
 	"regexp"
 	"sort"
@@ -20,6 +24,8 @@ import (
 
 	"llm-radar/internal/cache"
 	"llm-radar/internal/classifier"
+	"llm-radar/internal/filter"
+	"llm-radar/internal/history"
 	"llm-radar/internal/kb"
 	"llm-radar/internal/models"
 )
@@ -30,16 +36,62 @@ import (
 
 // StartWorkers spawns concurrent workers to test models.
 // The msgChan receives generic tea.Msg values that should be understood by the TUI layer.
+// Cancelling ctx stops handing out models and kills running probes; aborted
+// probes produce no result. It returns the number of probes aborted.
+// When cfg.Deadline expires, the same happens but every model left gets a
+// SKIPPED result, so the run still reports on all of its models.
 func StartWorkers(
+	ctx context.Context,
 	modelList []string,
 	cfg models.RunConfig,
 	compiledKB kb.Compiled,
 	resCache *cache.ResultCache,
 	msgChan chan tea.Msg,
 	processed *int32,
-) {
-	jobs := make(chan string, len(modelList))
+) int {
 	var wg sync.WaitGroup
+	var aborted int32
+	kbHash := compiledKB.Fingerprint()
+	prober := NewProviderRouter(compiledKB, cfg)
+
+	// Cached results are reported first so they don't take provider capacity.
+	var pending []string
+	for _, model := range modelList {
+		if cfg.UseCache {
+			if cached, ok := resCache.Get(CacheKey(model, cfg, kbHash)); ok {
+				cached.Reason += " (cached)"
+				notifyStart(msgChan, model)
+				atomic.AddInt32(processed, 1)
+				msgChan <- cached
+				continue
+			}
+		}
+		pending = append(pending, model)
+	}
+
+	sched := NewScheduler(pending, compiledKB.Config.ProviderLimits)
+	sched.SetCircuitBreaker(cfg.CircuitBreaker)
+	samples := newSampleSet(cfg.Samples)
+
+	// The deadline stops the run like a quit, except that the models left
+	// are reported as skipped instead of aborted.
+	runCtx := ctx
+	if cfg.Deadline > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, cfg.Deadline)
+		defer cancel()
+	}
+	stop := context.AfterFunc(runCtx, sched.Stop)
+	defer stop()
+
+	// expired reports a model cut off by the deadline: the samples it
+	// already has, or a skipped result.
+	expired := func(model string) models.ModelResult {
+		if res, ok := samples.flush(model); ok {
+			return res
+		}
+		return DeadlineResult(model, cfg)
+	}
 
 	for i := 0; i < cfg.Concurrency; i++ {
 		wg.Add(1)
@@ -47,96 +99,296 @@ func StartWorkers(
 
 		go func(delay time.Duration) {
 			defer wg.Done()
-			time.Sleep(delay)
-
-			for model := range jobs {
-				// Send worker start notification as a generic message
-				// The TUI layer will handle the actual message type
-				startMsg := struct {
-					Model string
-					Start time.Time
-				}{model, time.Now()}
-				msgChan <- startMsg
-
-				var res models.ModelResult
-				if cfg.UseCache {
-					if cached, ok := resCache.Get(model); ok {
-						res = cached
-						res.Reason += " (cached)"
-					}
+			if !sleepCtx(runCtx, delay) {
+				return
+			}
+
+			for {
+				model, ok := sched.Next()
+				if !ok {
+					return
+				}
+				notifyStart(msgChan, model)
+
+				prevWait, requeued := sched.Delay(model)
+				res, retryIn := testModel(runCtx, prober, model, cfg, compiledKB, sched, !requeued)
+				if ctx.Err() != nil {
+					atomic.AddInt32(&aborted, 1)
+					sched.Done(model)
+					return
+				}
+				if runCtx.Err() != nil {
+					sched.Done(model)
+					atomic.AddInt32(processed, 1)
+					msgChan <- expired(model)
+					return
+				}
+				if retryIn > 0 {
+					// Long rate-limit hint: retry at the end of the run
+					// instead of blocking this worker.
+					sched.RequeueAfter(model, retryIn)
+					sched.Done(model)
+					continue
 				}
+				if requeued {
+					res.Waited = true
+					res.WaitMs += prevWait.Milliseconds()
+				}
+				skipped := sched.Record(model, res.Category)
+				complete := true
+				if samples != nil {
+					res, complete = samples.add(res)
+				}
+				switch {
+				case !complete && sched.CircuitOpen(model):
+					// The provider's circuit is open: report the samples
+					// taken so far rather than probing again. Partial
+					// results are not cached.
+					res, _ = samples.flush(model)
+					complete = true
+				case !complete:
+					// Each sample goes through the scheduler, so provider
+					// limits apply to every probe.
+					sched.Later(model, cfg.SampleInterval)
+				case cfg.UseCache:
+					resCache.Set(CacheKey(model, cfg, kbHash), res)
+				}
+				sched.Done(model)
 
-				if res.Model == "" {
-					res = TestModel(model, cfg, compiledKB)
-					if cfg.UseCache {
-						resCache.Set(model, res)
+				if complete {
+					atomic.AddInt32(processed, 1)
+					msgChan <- res
+				}
+				for _, m := range skipped {
+					// Models waiting for their next sample keep the
+					// samples they have; only unprobed ones are skipped.
+					out, ok := samples.flush(m)
+					if !ok {
+						out = CircuitOpenResult(m, res, cfg.CircuitBreaker)
 					}
+					atomic.AddInt32(processed, 1)
+					msgChan <- out
 				}
-
-				atomic.AddInt32(processed, 1)
-				msgChan <- res
 			}
 		}(initialDelay)
 	}
 
-	for _, m := range modelList {
-		jobs <- m
-	}
-	close(jobs)
-
 	wg.Wait()
+	if ctx.Err() == nil && runCtx.Err() != nil {
+		for _, model := range sched.Drain() {
+			atomic.AddInt32(processed, 1)
+			msgChan <- expired(model)
+		}
+	}
+	prober.Close()
 	close(msgChan)
+	return int(aborted)
 }
 
-// TestModel tests a single model and returns the result.
-func TestModel(modelName string, cfg models.RunConfig, compiledKB kb.Compiled) models.ModelResult {
-	provider := ExtractProvider(modelName)
+// sampleSet collects repeated probes of each model until it has n of them.
+type sampleSet struct {
+	n       int
+	mu      sync.Mutex
+	results map[string][]models.ModelResult
+}
 
-	var lastOut string
-	var exitCode int
-	var duration time.Duration
+// newSampleSet returns nil when each model is probed once.
+func newSampleSet(n int) *sampleSet {
+	if n <= 1 {
+		return nil
+	}
+	return &sampleSet{n: n, results: make(map[string][]models.ModelResult)}
+}
 
-	for attempt := 0; attempt <= cfg.Retries; attempt++ {
-		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
-		start := time.Now()
+// add records a sample. Once the model has n samples it returns their
+// merged result and true.
+func (s *sampleSet) add(res models.ModelResult) (models.ModelResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		args := []string{"run", "--model", modelName}
-		args = append(args, cfg.Prompt)
+	list := append(s.results[res.Model], res)
+	if len(list) < s.n {
+		s.results[res.Model] = list
+		return res, false
+	}
+	delete(s.results, res.Model)
+	return models.MergeSamples(list), true
+}
 
-		out, code, err := ExecuteCommandSecure(ctx, "opencode", args...)
-		duration = time.Since(start)
-		cancel()
+// flush merges the samples a model has so far, reporting false if it has
+// none. It is safe to call on a nil sampleSet.
+func (s *sampleSet) flush(model string) (models.ModelResult, bool) {
+	if s == nil {
+		return models.ModelResult{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		lastOut = out
-		exitCode = code
+	list := s.results[model]
+	if len(list) == 0 {
+		return models.ModelResult{}, false
+	}
+	delete(s.results, model)
+	return models.MergeSamples(list), true
+}
 
-		if err == context.DeadlineExceeded {
-			exitCode = 124
-		}
+// CircuitOpenReason prefixes the reason of models skipped because their
+// provider's circuit breaker opened.
+const CircuitOpenReason = "Ignorado: circuito do provedor aberto"
+
+// CircuitOpenResult is the inferred result of a model skipped after
+// threshold consecutive failures of its provider, the last one being cause.
+func CircuitOpenResult(model string, cause models.ModelResult, threshold int) models.ModelResult {
+	return models.ModelResult{
+		Model:     model,
+		Provider:  ExtractProvider(model),
+		Category:  cause.Category,
+		Reason:    fmt.Sprintf("%s (%d× %s)", CircuitOpenReason, threshold, cause.Category),
+		Duration:  "0s",
+		Icon:      cause.Icon,
+		Probe:     cause.Probe,
+		Skipped:   true,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// DeadlineReason prefixes the reason of models left unprobed when the run
+// deadline expired.
+const DeadlineReason = "Ignorado: prazo da execução esgotado"
+
+// DeadlineResult is the result of a model not probed before cfg.Deadline.
+func DeadlineResult(model string, cfg models.RunConfig) models.ModelResult {
+	return models.ModelResult{
+		Model:     model,
+		Provider:  ExtractProvider(model),
+		Category:  models.CategorySkipped,
+		Reason:    fmt.Sprintf("%s (%s)", DeadlineReason, cfg.Deadline),
+		Duration:  "0s",
+		Icon:      models.CategoryIcons[models.CategorySkipped],
+		Probe:     cfg.Probe,
+		Skipped:   true,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// notifyStart sends the worker start notification as a generic message.
+// The TUI layer will handle the actual message type.
+func notifyStart(msgChan chan tea.Msg, model string) {
+	msgChan <- struct {
+		Model string
+		Start time.Time
+	}{model, time.Now()}
+}
+
+// TestModel probes a single model through p and classifies the result.
+// Each attempt runs under the model's KB timeout (kb.Compiled.TimeoutFor),
+// or cfg.Timeout when the KB sets none.
+func TestModel(p Prober, modelName string, cfg models.RunConfig, compiledKB kb.Compiled) models.ModelResult {
+	res, _ := testModel(context.Background(), p, modelName, cfg, compiledKB, nil, false)
+	return res
+}
+
+// testModel is TestModel with optional requeueing. Failed attempts are
+// retried according to the KB retry policy of their category. Rate-limit
+// hints up to the KB's MaxRetryWait replace the policy delay; when requeue
+// is true and a hint is longer, the probe gives up early and returns the
+// hinted wait instead of a verdict, so the caller can retry the model later.
+// Each retry takes a token from sched, which may be nil, so it respects the
+// provider's RPM limit. Cancelling parent aborts the running attempt and any
+// pending backoff.
+func testModel(parent context.Context, p Prober, modelName string, cfg models.RunConfig, compiledKB kb.Compiled, sched *Scheduler, requeue bool) (models.ModelResult, time.Duration) {
+	provider := ExtractProvider(modelName)
+	compiledKB = compiledKB.ForModel(modelName)
+
+	var attempts []attempt
+	var known Response
+	var waited time.Duration
+	baseTimeout := compiledKB.TimeoutFor(modelName, cfg.Timeout)
+	timeout := baseTimeout
 
-		if exitCode == 0 && compiledKB.SuccessRe.MatchString(lastOut) {
+	for {
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		resp, err := p.Probe(ctx, modelName, cfg)
+		cancel()
+
+		known = resp
+		a := attempt{output: resp.Output, exitCode: resp.ExitCode, duration: resp.Duration, timeout: timeout}
+		if errors.Is(err, context.DeadlineExceeded) {
+			a.exitCode = 124
+		}
+		a.category = attemptCategory(resp, a.exitCode, compiledKB)
+		attempts = append(attempts, a)
+		if a.category == "" {
 			break
 		}
 
-		if compiledKB.NotFoundRe.MatchString(lastOut) ||
-			compiledKB.AuthRe.MatchString(lastOut) ||
-			compiledKB.QuotaRe.MatchString(lastOut) {
+		policy, ok := compiledKB.Retries[a.category]
+		if !ok {
 			break
 		}
+		maxAttempts := policy.MaxAttempts
+		if maxAttempts == 0 {
+			maxAttempts = cfg.Retries + 1
+		}
+		tries := 0
+		for _, prev := range attempts {
+			if prev.category == a.category {
+				tries++
+			}
+		}
 
-		if compiledKB.RateLimitRe.MatchString(lastOut) && attempt < cfg.Retries {
-			backoff := time.Duration((attempt+1)*500) * time.Millisecond
-			time.Sleep(backoff)
-			continue
+		var hint time.Duration
+		var hinted bool
+		if a.category == models.CategoryRateLimited {
+			hint, hinted = compiledKB.RetryAfter(resp.Output, time.Now())
 		}
+		if hinted && hint > compiledKB.MaxRetryWait {
+			if requeue {
+				return models.ModelResult{}, hint
+			}
+			break
+		}
+		if tries >= maxAttempts {
+			break
+		}
+		wait := policy.Delay(tries, rand.Float64())
+		if hinted {
+			wait = hint
+			waited += hint
+		}
+		attempts[len(attempts)-1].backoff = wait
 
-		if exitCode == 124 {
+		timeout = baseTimeout
+		if policy.Timeout > 0 {
+			timeout = policy.Timeout
+		}
+		// Retries count against the provider's RPM limit like any probe.
+		if !sleepCtx(parent, wait) || !sched.Acquire(parent, provider) {
 			break
 		}
 	}
 
+	last := attempts[len(attempts)-1]
+	lastOut, exitCode, duration := last.output, last.exitCode, last.duration
+
 	outTrimmed := SmartTrim(lastOut, cfg.MaxOutputKB)
-	result := classifier.Classify(modelName, exitCode, outTrimmed, compiledKB)
+	result := classifier.Classify(modelName, exitCode, outTrimmed, last.timeout, compiledKB)
+	if known.Category != "" {
+		result = classifier.FromCategory(known.Category, known.Reason)
+	}
+
+	history := make([]models.Attempt, len(attempts))
+	for i, a := range attempts {
+		history[i] = models.Attempt{
+			Category:   a.category,
+			ExitCode:   a.exitCode,
+			DurationMs: a.duration.Milliseconds(),
+			BackoffMs:  a.backoff.Milliseconds(),
+			Output:     Truncate(strings.TrimSpace(a.output), attemptOutputMax),
+		}
+	}
+	// The final classification also covers successes and free models.
+	history[len(history)-1].Category = result.Category
 
 	return models.ModelResult{
 		Model:      modelName,
@@ -148,7 +400,59 @@ func TestModel(modelName string, cfg models.RunConfig, compiledKB kb.Compiled) m
 		DurationMs: duration.Milliseconds(),
 		Output:     outTrimmed,
 		ExitCode:   exitCode,
+		Probe:      cfg.Probe,
+		Waited:     waited > 0,
+		WaitMs:     waited.Milliseconds(),
+		Attempts:   history,
 		Timestamp:  time.Now().Format(time.RFC3339),
+	}, 0
+}
+
+// attemptOutputMax caps the output kept for each recorded attempt.
+const attemptOutputMax = 300
+
+// attempt is the outcome of one probe of a model.
+type attempt struct {
+	category string // "" for a successful answer
+	exitCode int
+	duration time.Duration
+	backoff  time.Duration // wait before the next attempt
+	timeout  time.Duration
+	output   string
+}
+
+// attemptCategory classifies a single attempt for the retry policy,
+// returning "" on success. It follows the error precedence of
+// classifier.Classify without the KB's free model lookups.
+func attemptCategory(resp Response, exitCode int, compiledKB kb.Compiled) string {
+	switch {
+	case exitCode == 0 && compiledKB.SuccessRe.MatchString(resp.Output):
+		return ""
+	case resp.Category != "":
+		return resp.Category
+	case compiledKB.NotFoundRe.MatchString(resp.Output):
+		return models.CategoryNotFound
+	case exitCode == 124 || compiledKB.TimeoutRe.MatchString(resp.Output):
+		return models.CategoryTimeout
+	case compiledKB.QuotaRe.MatchString(resp.Output):
+		return models.CategoryNoQuota
+	case compiledKB.AuthRe.MatchString(resp.Output):
+		return models.CategoryAuthFailed
+	case compiledKB.RateLimitRe.MatchString(resp.Output):
+		return models.CategoryRateLimited
+	}
+	return models.CategoryError
+}
+
+// sleepCtx sleeps for d and reports false if ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
 	}
 }
 
@@ -197,58 +501,206 @@ func ExecuteCommandSecure(ctx context.Context, name string, args ...string) (str
 	}
 }
 
-// DiscoverModelsCmd returns a Bubble Tea command that discovers available models.
-func DiscoverModelsCmd() tea.Cmd {
-	return func() tea.Msg {
-		out, err := exec.Command("opencode", "models").Output()
+// DetectOpencodeVersion returns the installed opencode version, or "unknown"
+// if it cannot be determined.
+func DetectOpencodeVersion() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, code, err := ExecuteCommandSecure(ctx, "opencode", "--version")
+	out = strings.TrimSpace(out)
+	if err != nil || code != 0 || out == "" {
+		return "unknown"
+	}
+	return out
+}
+
+// DiscoverModels lists the models reported by `opencode models`.
+func DiscoverModels() ([]string, error) {
+	out, err := exec.Command("opencode", "models").Output()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao descobrir modelos: %w", err)
+	}
+
+	lines := strings.Split(string(out), "\n")
+	var models []string
+	re := regexp.MustCompile(`^[A-Za-z0-9_-]+/[A-Za-z0-9._-]+$`)
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if re.MatchString(line) {
+			models = append(models, line)
+		}
+	}
+
+	sort.Strings(models)
+	return models, nil
+}
+
+// LoadModelList reads a curated model list from path, or from stdin when
+// path is "-". See ParseModelList for the format. An empty list is an error.
+func LoadModelList(path string) ([]string, error) {
+	r := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
 		if err != nil {
-			// Return error directly
-			return fmt.Errorf("falha ao descobrir modelos: %w", err)
+			return nil, fmt.Errorf("erro ao abrir lista de modelos: %w", err)
 		}
+		defer f.Close()
+		r = f
+	}
 
-		lines := strings.Split(string(out), "\n")
-		var models []string
-		re := regexp.MustCompile(`^[A-Za-z0-9_-]+/[A-Za-z0-9._-]+$`)
+	list, err := ParseModelList(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("lista de modelos vazia: %s", path)
+	}
+	return list, nil
+}
 
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if re.MatchString(line) {
-				models = append(models, line)
-			}
+// ParseModelList reads one provider/model per line. Blank lines and
+// comments starting with # are ignored; duplicates are dropped and the
+// original order is kept.
+func ParseModelList(r io.Reader) ([]string, error) {
+	var list []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if !strings.Contains(line, "/") || strings.ContainsAny(line, " \t") {
+			return nil, fmt.Errorf("linha %d: modelo inválido %q (esperado provider/model)", lineNo, line)
 		}
+		if !seen[line] {
+			seen[line] = true
+			list = append(list, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler lista de modelos: %w", err)
+	}
+
+	return list, nil
+}
 
-		sort.Strings(models)
-		// Return the slice directly - TUI layer will convert to DiscoveryMsg
-		return models
+// DiscoverModelsCmd returns a Bubble Tea command that discovers available
+// models, including those from backend discovery templates.
+func DiscoverModelsCmd(compiledKB kb.Compiled) tea.Cmd {
+	return func() tea.Msg {
+		var warnings []error
+		models, err := DiscoverAll(compiledKB, func(err error) { warnings = append(warnings, err) })
+		if err != nil {
+			// Return error directly
+			return err
+		}
+		return DiscoveryResult{Models: models, Warnings: warnings}
 	}
 }
 
+// DiscoveryResult is the message sent by DiscoverModelsCmd. Warnings holds
+// the discovery commands that failed and were skipped.
+type DiscoveryResult struct {
+	Models   []string
+	Warnings []error
+}
+
 // ============================================================================
 // HELPER FUNCTIONS
 // ============================================================================
 
-// PrioritizeModels reorders models to test free ones first.
-func PrioritizeModels(models []string, freeModels map[string]bool, zaiPrefix string) []string {
-	var free, zai, other []string
-
-	for _, m := range models {
-		if freeModels[m] {
-			free = append(free, m)
-		} else if strings.HasPrefix(m, zaiPrefix) {
-			zai = append(zai, m)
-		} else {
-			other = append(other, m)
-		}
+// CacheKey builds the cache key for a model under the current run conditions.
+func CacheKey(model string, cfg models.RunConfig, kbHash string) cache.Key {
+	return cache.Key{
+		Model:           model,
+		Prompt:          cfg.Prompt,
+		Probe:           cfg.Probe,
+		KBHash:          kbHash,
+		OpencodeVersion: cfg.OpencodeVersion,
+		Samples:         cfg.Samples,
+	}
+}
+
+// PrepareQueue applies the run's provider and include/exclude filters to the
+// discovered models and orders the remainder for testing.
+func PrepareQueue(modelList []string, cfg models.RunConfig, compiledKB kb.Compiled) ([]string, error) {
+	f, err := filter.New(cfg.Providers, cfg.Include, cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := f.Apply(modelList)
+	if len(selected) == 0 && len(modelList) > 0 {
+		return nil, fmt.Errorf("nenhum dos %d modelos corresponde aos filtros", len(modelList))
 	}
 
-	result := make([]string, 0, len(models))
-	result = append(result, free...)
-	result = append(result, zai...)
-	result = append(result, other...)
+	var hist history.History
+	if cfg.HistoryPath != "" {
+		// Without a readable history, rules on past runs match nothing.
+		hist, _ = history.Load(cfg.HistoryPath)
+	}
+	return PrioritizeByKB(selected, compiledKB, hist), nil
+}
 
+// PrioritizeByKB orders models by the KB priority rules, using hist for the
+// rules on past runs. Each model is queued with the first rule it matches;
+// models matching none go last. The input order is kept within each group.
+func PrioritizeByKB(modelList []string, compiledKB kb.Compiled, hist history.History) []string {
+	rules := compiledKB.Priorities
+	groups := make([][]string, len(rules)+1)
+	for _, m := range modelList {
+		i := 0
+		for i < len(rules) && !matchPriority(rules[i], m, compiledKB, hist) {
+			i++
+		}
+		groups[i] = append(groups[i], m)
+	}
+
+	result := make([]string, 0, len(modelList))
+	for _, g := range groups {
+		result = append(result, g...)
+	}
 	return result
 }
 
+// matchPriority reports whether a model matches every condition of a rule.
+func matchPriority(p kb.Priority, model string, compiledKB kb.Compiled, hist history.History) bool {
+	if !p.Filter.Match(model) {
+		return false
+	}
+	if _, free := compiledKB.Config.FreeModels[model]; p.Free && !free {
+		return false
+	}
+
+	past, seen := hist[model]
+	if p.LastCategories != nil && (!seen || !p.LastCategories[past.LastCategory]) {
+		return false
+	}
+	if p.MinSuccessRate > 0 && (!seen || past.SuccessRate() < p.MinSuccessRate) {
+		return false
+	}
+	return true
+}
+
+// CacheKeyFunc returns a function building cache keys for the given run.
+func CacheKeyFunc(cfg models.RunConfig, compiledKB kb.Compiled) func(model string) cache.Key {
+	kbHash := compiledKB.Fingerprint()
+	return func(model string) cache.Key {
+		return CacheKey(model, cfg, kbHash)
+	}
+}
+
 // SmartTrim truncates long output strings while preserving start and end.
 func SmartTrim(s string, maxKB int) string {
 	maxBytes := maxKB * 1024
@@ -1,11 +1,60 @@
 package worker
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"llm-radar/internal/history"
+	"llm-radar/internal/kb"
+	"llm-radar/internal/models"
 )
 
-func TestPrioritizeModels(t *testing.T) {
+// TestMain lets the test binary stand in for `opencode serve` when it is
+// launched through the wrapper written by installFakeServe.
+func TestMain(m *testing.M) {
+	if os.Getenv("LLM_RADAR_FAKE_SERVE") == "1" {
+		fs := flag.NewFlagSet("serve", flag.ExitOnError)
+		port := fs.Int("port", 0, "")
+		fs.String("hostname", "127.0.0.1", "")
+		fs.Parse(os.Args[2:]) // os.Args[1] is "serve"
+		stub := &serveStub{}
+		http.ListenAndServe(fmt.Sprintf("127.0.0.1:%d", *port), stub.handler())
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// freeModelsKB compiles the default KB with freeModels as its free models.
+func freeModelsKB(t *testing.T, freeModels map[string]bool) kb.Compiled {
+	t.Helper()
+	cfg := kb.DefaultConfig()
+	cfg.FreeModels = make(map[string]kb.ModelInfo)
+	for m := range freeModels {
+		cfg.FreeModels[m] = kb.ModelInfo{Category: models.CategoryFree}
+	}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
+
+func TestPrioritizeByKBFreeThenZai(t *testing.T) {
 	freeModels := map[string]bool{
 		"opencode/free-model": true,
 		"opencode/big-pickle": true,
@@ -20,7 +69,7 @@ func TestPrioritizeModels(t *testing.T) {
 		"zai-coding-plan/glm-4.7",
 	}
 
-	result := PrioritizeModels(models, freeModels, "zai-coding-plan/")
+	result := PrioritizeByKB(models, freeModelsKB(t, freeModels), nil)
 
 	// Free models should be first
 	if result[0] != "opencode/free-model" && result[0] != "opencode/big-pickle" {
@@ -117,19 +166,1124 @@ func TestExtractProvider(t *testing.T) {
 	}
 }
 
-func TestPrioritizeModelsEmptyInput(t *testing.T) {
-	result := PrioritizeModels([]string{}, map[string]bool{}, "zai-")
+func TestPrioritizeByKB(t *testing.T) {
+	cfg := kb.DefaultConfig()
+	cfg.Priority = []kb.PriorityRule{
+		{Models: []string{"*-coder*"}, MinSuccessRate: 0.5},
+		{LastCategories: []string{models.CategoryFree, models.CategoryAvailable}},
+		{Providers: []string{"groq"}},
+	}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hist := history.History{
+		"a/qwen-coder":  {LastCategory: models.CategoryAvailable, Probes: 4, Successes: 3},
+		"b/deep-coder":  {LastCategory: models.CategoryTimeout, Probes: 4, Successes: 1},
+		"c/llama":       {LastCategory: models.CategoryFree, Probes: 1, Successes: 1},
+		"groq/mixtral":  {LastCategory: models.CategoryAuthFailed, Probes: 1},
+		"d/never-tried": {},
+	}
+
+	input := []string{"d/never-tried", "groq/mixtral", "b/deep-coder", "c/llama", "a/qwen-coder", "e/other"}
+	got := PrioritizeByKB(input, compiled, hist)
+
+	// b/deep-coder fails the success rate, d/never-tried has no verdict.
+	want := []string{"a/qwen-coder", "c/llama", "groq/mixtral", "d/never-tried", "b/deep-coder", "e/other"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("PrioritizeByKB() = %v, want %v", got, want)
+	}
+}
+
+func TestPrioritizeByKBDefaultRules(t *testing.T) {
+	input := []string{"other/x", "zai-coding-plan/glm-4.7", "opencode/big-pickle"}
+	got := PrioritizeByKB(input, testKB(t), nil)
+
+	want := []string{"opencode/big-pickle", "zai-coding-plan/glm-4.7", "other/x"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("PrioritizeByKB() = %v, want %v", got, want)
+	}
+}
+
+func TestPrioritizeByKBEmptyInput(t *testing.T) {
+	result := PrioritizeByKB([]string{}, freeModelsKB(t, nil), nil)
 
 	if len(result) != 0 {
 		t.Error("Empty input should produce empty output")
 	}
 }
 
-func TestPrioritizeModelsNoFreeModels(t *testing.T) {
+func TestPrioritizeByKBNoFreeModels(t *testing.T) {
 	models := []string{"anthropic/claude", "openai/gpt-4"}
-	result := PrioritizeModels(models, map[string]bool{}, "zai-coding-plan/")
+	result := PrioritizeByKB(models, freeModelsKB(t, nil), nil)
 
 	if len(result) != 2 {
 		t.Error("Should preserve all models")
 	}
 }
+
+func TestPrepareQueueAppliesFilters(t *testing.T) {
+	compiled, err := kb.Compile(kb.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discovered := []string{
+		"anthropic/claude",
+		"groq/llama-3.1",
+		"opencode/big-pickle",
+		"opencode/gpt-5",
+	}
+	cfg := models.RunConfig{
+		Providers: []string{"opencode", "groq"},
+		Exclude:   []string{"*/gpt-*"},
+	}
+
+	queue, err := PrepareQueue(discovered, cfg, compiled)
+	if err != nil {
+		t.Fatalf("PrepareQueue failed: %v", err)
+	}
+
+	if len(queue) != 2 {
+		t.Fatalf("Expected 2 models, got %v", queue)
+	}
+	if queue[0] != "opencode/big-pickle" {
+		t.Errorf("Free model should still be prioritized first, got %v", queue)
+	}
+}
+
+func TestPrepareQueueNoMatch(t *testing.T) {
+	compiled, _ := kb.Compile(kb.DefaultConfig())
+	cfg := models.RunConfig{Providers: []string{"nonexistent"}}
+
+	if _, err := PrepareQueue([]string{"groq/llama"}, cfg, compiled); err == nil {
+		t.Error("Expected error when filters remove every model")
+	}
+}
+
+func TestParseModelList(t *testing.T) {
+	input := `# Team fallback chain
+opencode/big-pickle
+groq/llama-3.3-70b   # fast
+
+openrouter/qwen/qwen3-coder:free
+opencode/big-pickle
+`
+	list, err := ParseModelList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseModelList failed: %v", err)
+	}
+
+	expected := []string{
+		"opencode/big-pickle",
+		"groq/llama-3.3-70b",
+		"openrouter/qwen/qwen3-coder:free",
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, list)
+	}
+	for i := range expected {
+		if list[i] != expected[i] {
+			t.Errorf("list[%d] = %q, want %q", i, list[i], expected[i])
+		}
+	}
+}
+
+func TestParseModelListInvalidLine(t *testing.T) {
+	if _, err := ParseModelList(strings.NewReader("opencode/big-pickle\nnot-a-model\n")); err == nil {
+		t.Error("Expected error for line without provider")
+	}
+}
+
+func TestLoadModelListEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.txt")
+	if err := os.WriteFile(path, []byte("# nothing here\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadModelList(path); err == nil {
+		t.Error("Expected error for empty model list")
+	}
+}
+
+func testKB(t *testing.T) kb.Compiled {
+	t.Helper()
+	compiled, err := kb.Compile(kb.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
+
+func TestTestModelUsesProber(t *testing.T) {
+	cfg := models.RunConfig{Prompt: "p", Timeout: time.Second, MaxOutputKB: 64, Probe: "default"}
+
+	tests := []struct {
+		name     string
+		resp     Response
+		err      error
+		category string
+	}{
+		{"success", Response{Output: "2, 3, 5"}, nil, models.CategoryAvailable},
+		{"auth", Response{Output: "401 unauthorized", ExitCode: 1}, nil, models.CategoryAuthFailed},
+		{"timeout", Response{ExitCode: 1}, context.DeadlineExceeded, models.CategoryTimeout},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		p := ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+			calls++
+			resp := tt.resp
+			resp.Duration = 150 * time.Millisecond
+			return resp, tt.err
+		})
+
+		res := TestModel(p, "acme/model", cfg, testKB(t))
+		if res.Category != tt.category {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.category, res.Category)
+		}
+		if calls != 1 {
+			t.Errorf("%s: expected 1 probe call, got %d", tt.name, calls)
+		}
+		if res.DurationMs != 150 || res.Provider != "acme" || res.Probe != "default" {
+			t.Errorf("%s: unexpected result metadata: %+v", tt.name, res)
+		}
+	}
+}
+
+func TestProviderRouter(t *testing.T) {
+	cfg := kb.DefaultConfig()
+	cfg.Backends = map[string]kb.BackendConfig{"cli": {Type: kb.BackendOpencode}}
+	cfg.ProviderBackends = map[string]string{"groq": "cli"}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewProviderRouter(compiled, models.RunConfig{})
+	var got []string
+	fake := func(name string) Prober {
+		return ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+			got = append(got, name+":"+model)
+			return Response{}, nil
+		})
+	}
+	r.backends["cli"] = fake("cli")
+	r.backends[kb.BackendOpencode] = fake("opencode")
+
+	r.Probe(context.Background(), "groq/llama", models.RunConfig{})
+	r.Probe(context.Background(), "openai/gpt", models.RunConfig{})
+
+	want := []string{"cli:groq/llama", "opencode:openai/gpt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Routed to %v, want %v", got, want)
+	}
+}
+
+func newOpenAIStub(t *testing.T, handler http.HandlerFunc) *OpenAIProber {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewOpenAIProber(kb.BackendConfig{Type: kb.BackendOpenAI, BaseURL: srv.URL + "/v1/", APIKeyEnv: "TEST_API_KEY"})
+}
+
+func TestOpenAIProberSuccess(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "secret")
+
+	p := newOpenAIStub(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Unexpected Authorization header %q", got)
+		}
+		var req chatRequest
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &req)
+		if req.Model != "llama-3.3-70b" || req.Messages[0].Content != "prompt" {
+			t.Errorf("Unexpected request %+v", req)
+		}
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"2, 3, 5"}}]}`)
+	})
+
+	resp, err := p.Probe(context.Background(), "groq/llama-3.3-70b", models.RunConfig{Prompt: "prompt"})
+	if err != nil || resp.ExitCode != 0 || resp.Output != "2, 3, 5" {
+		t.Errorf("Unexpected response %+v, err=%v", resp, err)
+	}
+}
+
+func TestOpenAIProberErrorMapping(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "secret")
+
+	tests := []struct {
+		status   int
+		body     string
+		category string
+	}{
+		{401, `{"error":{"message":"Invalid API Key","type":"invalid_request_error","code":"invalid_api_key"}}`, models.CategoryAuthFailed},
+		{403, `{}`, models.CategoryAuthFailed},
+		{404, `{"error":{"message":"The model does not exist"}}`, models.CategoryNotFound},
+		{400, `{"error":{"message":"unknown model","code":"model_not_found"}}`, models.CategoryNotFound},
+		{429, `{"error":{"message":"Rate limit reached for requests"}}`, models.CategoryRateLimited},
+		{429, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota"}}`, models.CategoryNoQuota},
+		{429, `{"error":{"message":"Rate limit reached on tokens per min. Visit https://platform.openai.com/account/billing to increase your quota.","type":"tokens","code":"rate_limit_exceeded"}}`, models.CategoryRateLimited},
+		{429, `{"error":{"message":"Quota exceeded","code":"insufficient_quota"}}`, models.CategoryNoQuota},
+		{402, `payment required`, models.CategoryNoQuota},
+		{403, `{"error":{"message":"Your credit balance is too low"}}`, models.CategoryNoQuota},
+		{401, `{"error":{"message":"Invalid key, see your billing settings"}}`, models.CategoryAuthFailed},
+		{404, `{"error":{"message":"Model not available on free credits"}}`, models.CategoryNotFound},
+		{500, `{"error":{"message":"internal"}}`, ""},
+	}
+
+	for _, tt := range tests {
+		p := newOpenAIStub(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			io.WriteString(w, tt.body)
+		})
+
+		resp, _ := p.Probe(context.Background(), "acme/model", models.RunConfig{})
+		if resp.Category != tt.category {
+			t.Errorf("HTTP %d %s: expected %q, got %q", tt.status, tt.body, tt.category, resp.Category)
+		}
+		if resp.ExitCode == 0 {
+			t.Errorf("HTTP %d: expected non-zero exit code", tt.status)
+		}
+	}
+}
+
+func TestOpenAIProberMissingKey(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "")
+
+	p := newOpenAIStub(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("No request should be sent without an API key")
+	})
+
+	resp, _ := p.Probe(context.Background(), "acme/model", models.RunConfig{})
+	if resp.Category != models.CategoryAuthFailed {
+		t.Errorf("Expected AUTH_FAILED, got %q", resp.Category)
+	}
+}
+
+func TestOpenAIProberTimeout(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "secret")
+
+	p := newOpenAIStub(t, func(w http.ResponseWriter, r *http.Request) {
+		// Reading the body lets the server notice the client going away.
+		io.ReadAll(r.Body)
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	resp, err := p.Probe(ctx, "acme/model", models.RunConfig{})
+	if resp.ExitCode != 124 || err == nil {
+		t.Errorf("Expected timeout, got %+v, err=%v", resp, err)
+	}
+}
+
+func TestTestModelUsesBackendCategory(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "secret")
+
+	calls := 0
+	p := newOpenAIStub(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"error":{"message":"slow down"}}`)
+	})
+
+	cfg := models.RunConfig{Timeout: time.Second, Retries: 1, MaxOutputKB: 64}
+	res := TestModel(p, "acme/model", cfg, testKB(t))
+	if res.Category != models.CategoryRateLimited || res.Reason != "HTTP 429: slow down" {
+		t.Errorf("Expected RATE_LIMITED from HTTP status, got %s (%s)", res.Category, res.Reason)
+	}
+	if calls != 2 {
+		t.Errorf("Expected rate-limited probe to be retried once, got %d calls", calls)
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	got := ExpandTemplate("ollama run {name} {prompt}", "ollama/llama3:8b", "Reply with only: 2, 3, 5")
+	want := []string{"ollama", "run", "llama3:8b", "Reply with only: 2, 3, 5"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("ExpandTemplate() = %q, want %q", got, want)
+	}
+
+	got = ExpandTemplate("llm -m {model} --provider={provider}", "groq/llama", "")
+	if got[2] != "groq/llama" || got[3] != "--provider=groq" {
+		t.Errorf("Unexpected expansion %q", got)
+	}
+}
+
+// installFakeCLI writes an executable shell script named name to a temporary
+// directory and makes that directory the only PATH entry.
+func installFakeCLI(t *testing.T, name, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func commandKB(t *testing.T, backend kb.BackendConfig) kb.Compiled {
+	t.Helper()
+	cfg := kb.DefaultConfig()
+	cfg.Backends = map[string]kb.BackendConfig{"local": backend}
+	cfg.ProviderBackends = map[string]string{"ollama": "local"}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
+
+func TestCommandProber(t *testing.T) {
+	installFakeCLI(t, "fakecli", `
+if [ "$1" = "list" ]; then
+  echo "NAME            ID      SIZE"
+  echo "llama3:8b       abc     4.7 GB"
+  echo "qwen2.5:7b      def     4.4 GB"
+  exit 0
+fi
+if [ "$2" = "missing:1b" ]; then
+  echo "pull model manifest: file does not exist"
+  exit 1
+fi
+echo "answer: 2, 3, 5"
+`)
+
+	compiled := commandKB(t, kb.BackendConfig{
+		Type:          kb.BackendCommand,
+		Command:       "fakecli run {name} {prompt}",
+		Discover:      "fakecli list",
+		DiscoverRegex: `^(\S+:\S+)\s`,
+		NotFoundRegex: `(?i)file does not exist`,
+	})
+	cfg := models.RunConfig{Prompt: "Reply with only: 2, 3, 5", Timeout: 5 * time.Second, MaxOutputKB: 64}
+	prober := NewProviderRouter(compiled, cfg)
+
+	if res := TestModel(prober, "ollama/llama3:8b", cfg, compiled); res.Category != models.CategoryAvailable {
+		t.Errorf("Expected AVAILABLE, got %s (%s)", res.Category, res.Output)
+	}
+	if res := TestModel(prober, "ollama/missing:1b", cfg, compiled); res.Category != models.CategoryNotFound {
+		t.Errorf("Expected NOT_FOUND from backend regex, got %s (%s)", res.Category, res.Output)
+	}
+
+	list, err := DiscoverAll(compiled, nil)
+	if err != nil {
+		t.Fatalf("DiscoverAll failed: %v", err)
+	}
+	want := []string{"ollama/llama3:8b", "ollama/qwen2.5:7b"}
+	if strings.Join(list, ",") != strings.Join(want, ",") {
+		t.Errorf("DiscoverAll() = %v, want %v", list, want)
+	}
+}
+
+func TestDiscoverAllDefaultParser(t *testing.T) {
+	installFakeCLI(t, "fakecli", `
+if [ "$1" = "broken" ]; then
+  echo "connection refused" >&2
+  exit 1
+fi
+echo "NAME            ID      SIZE"
+echo "llama3:8b       abc     4.7 GB"
+echo "qwen2.5:7b      def     4.4 GB"
+`)
+
+	cfg := kb.DefaultConfig()
+	cfg.Backends = map[string]kb.BackendConfig{
+		"local":  {Type: kb.BackendCommand, Command: "fakecli run {name}", Discover: "fakecli list"},
+		"remote": {Type: kb.BackendCommand, Command: "fakecli run {name}", Discover: "fakecli broken"},
+	}
+	cfg.ProviderBackends = map[string]string{"ollama": "local", "lab": "remote"}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []error
+	list, err := DiscoverAll(compiled, func(err error) { warnings = append(warnings, err) })
+	if err != nil {
+		t.Fatalf("DiscoverAll failed: %v", err)
+	}
+	want := []string{"ollama/llama3:8b", "ollama/qwen2.5:7b"}
+	if strings.Join(list, ",") != strings.Join(want, ",") {
+		t.Errorf("DiscoverAll() = %v, want %v", list, want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "lab") {
+		t.Errorf("Expected one warning for the failing lab discovery, got %v", warnings)
+	}
+}
+
+// serveStub is a minimal stand-in for the `opencode serve` session API.
+// Model "test/auth" fails authentication and "test/slow" never answers.
+type serveStub struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+	nextID   int32
+	aborted  int32
+}
+
+func (s *serveStub) handler() http.Handler {
+	s.sessions = make(map[string]bool)
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /session", func(w http.ResponseWriter, r *http.Request) {
+		id := fmt.Sprintf("ses_%d", atomic.AddInt32(&s.nextID, 1))
+		s.mu.Lock()
+		s.sessions[id] = true
+		s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+	})
+
+	mux.HandleFunc("POST /session/{id}/message", func(w http.ResponseWriter, r *http.Request) {
+		var prompt servePrompt
+		json.NewDecoder(r.Body).Decode(&prompt)
+
+		switch prompt.ProviderID + "/" + prompt.ModelID {
+		case "test/auth":
+			io.WriteString(w, `{"info":{"error":{"name":"ProviderAuthError","data":{"message":"invalid key"}}},"parts":[]}`)
+		case "test/slow":
+			<-r.Context().Done()
+		default:
+			io.WriteString(w, `{"info":{},"parts":[{"type":"step-start"},{"type":"text","text":"2, 3, 5"}]}`)
+		}
+	})
+
+	mux.HandleFunc("POST /session/{id}/abort", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.aborted, 1)
+		io.WriteString(w, "true")
+	})
+
+	mux.HandleFunc("DELETE /session/{id}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		delete(s.sessions, r.PathValue("id"))
+		s.mu.Unlock()
+		io.WriteString(w, "true")
+	})
+
+	return mux
+}
+
+func (s *serveStub) openSessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+func TestServeProberAttach(t *testing.T) {
+	stub := &serveStub{}
+	srv := httptest.NewServer(stub.handler())
+	defer srv.Close()
+
+	cfg := models.RunConfig{Prompt: "p", Timeout: 5 * time.Second, MaxOutputKB: 64, Serve: srv.URL}
+	compiled := testKB(t)
+	prober := NewProviderRouter(compiled, cfg)
+	defer prober.Close()
+
+	if res := TestModel(prober, "test/good", cfg, compiled); res.Category != models.CategoryAvailable {
+		t.Errorf("Expected AVAILABLE, got %s (%s)", res.Category, res.Output)
+	}
+	if res := TestModel(prober, "test/auth", cfg, compiled); res.Category != models.CategoryAuthFailed {
+		t.Errorf("Expected AUTH_FAILED, got %s (%s)", res.Category, res.Output)
+	}
+	if n := stub.openSessions(); n != 0 {
+		t.Errorf("Expected sessions to be deleted, %d still open", n)
+	}
+}
+
+func TestServeProberTimeout(t *testing.T) {
+	stub := &serveStub{}
+	srv := httptest.NewServer(stub.handler())
+	defer srv.Close()
+
+	p := NewServeProber(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	resp, err := p.Probe(ctx, "test/slow", models.RunConfig{Prompt: "p"})
+	if resp.ExitCode != 124 || err == nil {
+		t.Errorf("Expected timeout, got %+v, err=%v", resp, err)
+	}
+	if atomic.LoadInt32(&stub.aborted) != 1 || stub.openSessions() != 0 {
+		t.Error("Timed-out session should be aborted and deleted")
+	}
+}
+
+// installFakeServe puts an opencode wrapper on PATH that runs this test
+// binary as a serve stub.
+func installFakeServe(t *testing.T) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	installFakeCLI(t, "opencode", fmt.Sprintf("LLM_RADAR_FAKE_SERVE=1 exec %q \"$@\"\n", exe))
+}
+
+func TestServeProberStartsServer(t *testing.T) {
+	installFakeServe(t)
+
+	p := NewServeProber(ServeAuto)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := p.Probe(ctx, "test/good", models.RunConfig{Prompt: "p"})
+	if err != nil || resp.Output != "2, 3, 5" {
+		t.Fatalf("Unexpected response %+v, err=%v", resp, err)
+	}
+
+	p.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(5 * time.Second):
+		t.Error("Started server should exit on Close")
+	}
+}
+
+func TestSchedulerSkipsProvidersAtCapacity(t *testing.T) {
+	limits := map[string]kb.ProviderLimit{"groq": {MaxInFlight: 1}}
+	s := NewScheduler([]string{"groq/a", "groq/b", "cerebras/c"}, limits)
+
+	first, _ := s.Next()
+	second, _ := s.Next()
+	if first != "groq/a" || second != "cerebras/c" {
+		t.Errorf("Expected groq/a then cerebras/c, got %s then %s", first, second)
+	}
+
+	got := make(chan string)
+	go func() {
+		m, _ := s.Next()
+		got <- m
+	}()
+
+	select {
+	case m := <-got:
+		t.Fatalf("groq/b should wait for groq capacity, got %s", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.Done("groq/a")
+	select {
+	case m := <-got:
+		if m != "groq/b" {
+			t.Errorf("Expected groq/b, got %s", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("groq/b was not released after Done")
+	}
+}
+
+func TestSchedulerRateLimit(t *testing.T) {
+	// 600 RPM with burst 1: one probe every 100ms.
+	limits := map[string]kb.ProviderLimit{"groq": {RPM: 600}}
+	s := NewScheduler([]string{"groq/a", "groq/b", "groq/c"}, limits)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		m, ok := s.Next()
+		if !ok {
+			t.Fatal("Scheduler ended early")
+		}
+		s.Done(m)
+	}
+
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("Expected RPM pacing of ~200ms for 3 probes, took %s", elapsed)
+	}
+	if _, ok := s.Next(); ok {
+		t.Error("Next should report the end of the queue")
+	}
+}
+
+func TestSchedulerWaitsForRequeue(t *testing.T) {
+	s := NewScheduler([]string{"groq/a"}, nil)
+
+	m, _ := s.Next()
+	done := make(chan bool)
+	go func() {
+		_, ok := s.Next()
+		done <- ok
+	}()
+
+	s.RequeueAfter(m, 0)
+	s.Done(m)
+	if ok := <-done; !ok {
+		t.Error("Requeued model should be handed out again")
+	}
+}
+
+func TestTestModelHonorsRetryHint(t *testing.T) {
+	calls := 0
+	p := ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+		calls++
+		if calls == 1 {
+			return Response{Output: "Rate limit reached. Please try again in 50ms.", ExitCode: 1}, nil
+		}
+		return Response{Output: "2, 3, 5"}, nil
+	})
+
+	cfg := models.RunConfig{Timeout: time.Second, Retries: 1, MaxOutputKB: 64}
+	res := TestModel(p, "acme/model", cfg, testKB(t))
+	if res.Category != models.CategoryAvailable || !res.Waited || res.WaitMs != 50 {
+		t.Errorf("Expected AVAILABLE after a 50ms wait, got %s waited=%v wait_ms=%d", res.Category, res.Waited, res.WaitMs)
+	}
+}
+
+func TestTestModelRequeuesLongRetryHint(t *testing.T) {
+	p := ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+		return Response{Output: "429 rate limit, retry after 120", ExitCode: 1}, nil
+	})
+	cfg := models.RunConfig{Timeout: time.Second, Retries: 1, MaxOutputKB: 64}
+
+	if _, retryIn := testModel(context.Background(), p, "acme/model", cfg, testKB(t), nil, true); retryIn != 120*time.Second {
+		t.Errorf("Expected requeue after 120s, got %v", retryIn)
+	}
+
+	start := time.Now()
+	res := TestModel(p, "acme/model", cfg, testKB(t))
+	if res.Category != models.CategoryRateLimited || res.Waited {
+		t.Errorf("Expected immediate RATE_LIMITED verdict, got %s waited=%v", res.Category, res.Waited)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Hints above the maximum wait should not block the worker")
+	}
+}
+
+func TestSchedulerRequeueAfter(t *testing.T) {
+	s := NewScheduler([]string{"groq/a", "cerebras/b"}, nil)
+
+	a, _ := s.Next()
+	s.RequeueAfter(a, 100*time.Millisecond)
+	s.Done(a)
+
+	if m, _ := s.Next(); m != "cerebras/b" {
+		t.Errorf("Expected cerebras/b before the requeued model, got %s", m)
+	}
+	s.Done("cerebras/b")
+
+	start := time.Now()
+	if m, _ := s.Next(); m != "groq/a" {
+		t.Errorf("Expected requeued groq/a, got %s", m)
+	}
+	if time.Since(start) < 80*time.Millisecond {
+		t.Error("Requeued model was handed out before its delay")
+	}
+	if d, ok := s.Delay("groq/a"); !ok || d != 100*time.Millisecond {
+		t.Errorf("Expected recorded delay of 100ms, got %v (%v)", d, ok)
+	}
+}
+
+func TestSchedulerStop(t *testing.T) {
+	s := NewScheduler([]string{"groq/a", "groq/b"}, map[string]kb.ProviderLimit{"groq": {MaxInFlight: 1}})
+	s.Next()
+
+	done := make(chan bool)
+	go func() {
+		_, ok := s.Next()
+		done <- ok
+	}()
+
+	s.Stop()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("Next should return false after Stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not wake a blocked Next")
+	}
+	if left := s.Drain(); len(left) != 1 {
+		t.Errorf("Expected the unstarted model to stay queued, got %v", left)
+	}
+}
+
+func TestStartWorkersCancel(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	installFakeCLI(t, "opencode", "exec "+sleep+" 30\n")
+
+	cfg := models.RunConfig{Prompt: "p", Timeout: 30 * time.Second, Concurrency: 2, MaxOutputKB: 64}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgChan := make(chan tea.Msg, 10)
+	var processed int32
+	abortedCh := make(chan int, 1)
+	go func() {
+		abortedCh <- StartWorkers(ctx, []string{"acme/a", "acme/b", "acme/c"}, cfg, testKB(t), nil, msgChan, &processed)
+	}()
+
+	for started := 0; started < 2; {
+		msg := <-msgChan
+		if _, ok := msg.(models.ModelResult); ok {
+			t.Fatal("No probe should finish before the cancel")
+		}
+		started++
+	}
+	// Give the fake CLI a moment to start.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	var results int
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-msgChan:
+			if !ok {
+				if n := <-abortedCh; n != 2 {
+					t.Errorf("Expected 2 aborted probes, got %d", n)
+				}
+				if results != 0 || processed != 0 {
+					t.Errorf("Aborted probes should not report results, got %d", results)
+				}
+				return
+			}
+			if _, ok := msg.(models.ModelResult); ok {
+				results++
+			}
+		case <-deadline:
+			t.Fatal("Workers did not stop after cancel")
+		}
+	}
+}
+
+func TestStartWorkersDeadline(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	installFakeCLI(t, "opencode", "exec "+sleep+" 30\n")
+
+	cfg := models.RunConfig{Prompt: "p", Timeout: 30 * time.Second, Concurrency: 2, MaxOutputKB: 64,
+		Deadline: 300 * time.Millisecond}
+	msgChan := make(chan tea.Msg, 10)
+	var processed int32
+	abortedCh := make(chan int, 1)
+	start := time.Now()
+	go func() {
+		abortedCh <- StartWorkers(context.Background(), []string{"acme/a", "acme/b", "acme/c"}, cfg, testKB(t), nil, msgChan, &processed)
+	}()
+
+	skipped := make(map[string]bool)
+	for msg := range msgChan {
+		res, ok := msg.(models.ModelResult)
+		if !ok {
+			continue
+		}
+		if res.Category != models.CategorySkipped || !res.Skipped || !strings.HasPrefix(res.Reason, DeadlineReason) {
+			t.Errorf("%s: expected a SKIPPED deadline result, got %s (%s)", res.Model, res.Category, res.Reason)
+		}
+		skipped[res.Model] = true
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Deadline should cancel running probes, run took %s", elapsed)
+	}
+	if n := <-abortedCh; n != 0 {
+		t.Errorf("Expected no aborted probes, got %d", n)
+	}
+	// Two models were cut off mid-probe, the third never started.
+	if len(skipped) != 3 || processed != 3 {
+		t.Errorf("Expected all 3 models skipped, got %v (processed %d)", skipped, processed)
+	}
+}
+
+func TestSchedulerCircuitBreaker(t *testing.T) {
+	s := NewScheduler([]string{"anthropic/a", "anthropic/b", "groq/x", "anthropic/c", "anthropic/d"}, nil)
+	s.SetCircuitBreaker(2)
+
+	a, _ := s.Next()
+	if skipped := s.Record(a, models.CategoryAuthFailed); skipped != nil {
+		t.Errorf("One failure should not open the circuit, skipped %v", skipped)
+	}
+	s.Done(a)
+
+	b, _ := s.Next()
+	skipped := s.Record(b, models.CategoryNoQuota)
+	s.Done(b)
+	if len(skipped) != 2 || skipped[0] != "anthropic/c" || skipped[1] != "anthropic/d" {
+		t.Errorf("Expected the remaining anthropic models to be skipped, got %v", skipped)
+	}
+	if m, _ := s.Next(); m != "groq/x" {
+		t.Errorf("Other providers should keep running, got %s", m)
+	}
+}
+
+func TestSchedulerCircuitBreakerResets(t *testing.T) {
+	s := NewScheduler([]string{"anthropic/a", "anthropic/b", "anthropic/c", "anthropic/d"}, nil)
+	s.SetCircuitBreaker(2)
+
+	for _, category := range []string{models.CategoryAuthFailed, models.CategoryAvailable, models.CategoryAuthFailed} {
+		m, _ := s.Next()
+		if skipped := s.Record(m, category); skipped != nil {
+			t.Errorf("Non-consecutive failures should not open the circuit, skipped %v", skipped)
+		}
+		s.Done(m)
+	}
+}
+
+func TestStartWorkersCircuitBreaker(t *testing.T) {
+	installFakeCLI(t, "opencode", "echo 'Error: 401 unauthorized'\nexit 1\n")
+
+	cfg := models.RunConfig{Prompt: "p", Timeout: 5 * time.Second, Concurrency: 1, MaxOutputKB: 64, CircuitBreaker: 2}
+	msgChan := make(chan tea.Msg, 20)
+	var processed int32
+	go StartWorkers(context.Background(), []string{"acme/a", "acme/b", "acme/c", "acme/d"}, cfg, testKB(t), nil, msgChan, &processed)
+
+	var probed, skipped int
+	for msg := range msgChan {
+		res, ok := msg.(models.ModelResult)
+		if !ok {
+			continue
+		}
+		if res.Category != models.CategoryAuthFailed {
+			t.Errorf("%s: expected AUTH_FAILED, got %s", res.Model, res.Category)
+		}
+		if res.Skipped {
+			skipped++
+			if !strings.HasPrefix(res.Reason, CircuitOpenReason) {
+				t.Errorf("Unexpected reason for skipped model: %s", res.Reason)
+			}
+		} else {
+			probed++
+		}
+	}
+	if probed != 2 || skipped != 2 || processed != 4 {
+		t.Errorf("Expected 2 probed and 2 skipped models, got %d/%d (processed %d)", probed, skipped, processed)
+	}
+}
+
+func TestStartWorkersSamples(t *testing.T) {
+	// Every third probe fails, so three samples give two successes.
+	counter := filepath.Join(t.TempDir(), "count")
+	installFakeCLI(t, "opencode", fmt.Sprintf(`
+n=0
+[ -f %[1]q ] && read n < %[1]q
+n=$((n+1))
+echo $n > %[1]q
+if [ $((n %% 3)) -eq 0 ]; then
+  echo 'Error: 401 unauthorized'
+  exit 1
+fi
+echo 'answer: 2, 3, 5'
+`, counter))
+
+	cfg := models.RunConfig{Prompt: "p", Timeout: 5 * time.Second, Concurrency: 1, MaxOutputKB: 64,
+		Samples: 3, SampleInterval: 10 * time.Millisecond}
+	msgChan := make(chan tea.Msg, 20)
+	var processed int32
+	go StartWorkers(context.Background(), []string{"acme/a"}, cfg, testKB(t), nil, msgChan, &processed)
+
+	var results []models.ModelResult
+	for msg := range msgChan {
+		if res, ok := msg.(models.ModelResult); ok {
+			results = append(results, res)
+		}
+	}
+	if len(results) != 1 || processed != 1 {
+		t.Fatalf("Expected one merged result, got %d (processed %d)", len(results), processed)
+	}
+
+	res := results[0]
+	if res.Samples == nil {
+		t.Fatal("Expected sample stats on the merged result")
+	}
+	if res.Samples.Count != 3 || res.Samples.Successes != 2 {
+		t.Errorf("Expected 2/3 successes, got %d/%d", res.Samples.Successes, res.Samples.Count)
+	}
+	if res.Category != models.CategoryAvailable || res.Samples.Majority != models.CategoryAvailable {
+		t.Errorf("Expected majority AVAILABLE, got %s (majority %s)", res.Category, res.Samples.Majority)
+	}
+}
+
+func TestStartWorkersCircuitBreakerSamples(t *testing.T) {
+	installFakeCLI(t, "opencode", "echo 'Error: 401 unauthorized'\nexit 1\n")
+
+	// With one worker, acme/a is sampled once and requeued, then acme/b
+	// opens the circuit: both keep their sample and only acme/c is skipped.
+	cfg := models.RunConfig{Prompt: "p", Timeout: 5 * time.Second, Concurrency: 1, MaxOutputKB: 64,
+		CircuitBreaker: 2, Samples: 2, SampleInterval: 10 * time.Millisecond}
+	msgChan := make(chan tea.Msg, 20)
+	var processed int32
+	go StartWorkers(context.Background(), []string{"acme/a", "acme/b", "acme/c"}, cfg, testKB(t), nil, msgChan, &processed)
+
+	byModel := make(map[string]models.ModelResult)
+	for msg := range msgChan {
+		if res, ok := msg.(models.ModelResult); ok {
+			byModel[res.Model] = res
+		}
+	}
+	if len(byModel) != 3 || processed != 3 {
+		t.Fatalf("Expected 3 results, got %d (processed %d)", len(byModel), processed)
+	}
+	for _, m := range []string{"acme/a", "acme/b"} {
+		res := byModel[m]
+		if res.Skipped || res.Samples == nil || res.Samples.Count != 1 {
+			t.Errorf("%s: expected its probed sample to be kept, got %+v", m, res)
+		}
+	}
+	if res := byModel["acme/c"]; !res.Skipped || !strings.HasPrefix(res.Reason, CircuitOpenReason) {
+		t.Errorf("acme/c: expected a circuit-open skip, got %+v", res)
+	}
+}
+
+func TestRetriesRespectRPM(t *testing.T) {
+	cfg := kb.DefaultConfig()
+	cfg.RetryPolicies = map[string]kb.RetryPolicy{
+		models.CategoryError: {MaxAttempts: 3, BaseDelay: "1ms"},
+	}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := models.RunConfig{Timeout: time.Second, MaxOutputKB: 64}
+
+	// 300 RPM is one request every 200ms.
+	sched := NewScheduler([]string{"acme/model"}, map[string]kb.ProviderLimit{"acme": {RPM: 300}})
+	model, _ := sched.Next()
+
+	var calls []time.Time
+	p := ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+		calls = append(calls, time.Now())
+		return Response{Output: "segfault", ExitCode: 1}, nil
+	})
+	testModel(context.Background(), p, model, run, compiled, sched, false)
+
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(calls))
+	}
+	for i := 1; i < len(calls); i++ {
+		if gap := calls[i].Sub(calls[i-1]); gap < 150*time.Millisecond {
+			t.Errorf("Retry %d ran %s after the previous attempt, faster than the RPM limit", i, gap)
+		}
+	}
+}
+
+func TestTestModelRetryPolicies(t *testing.T) {
+	cfg := kb.DefaultConfig()
+	cfg.RetryPolicies = map[string]kb.RetryPolicy{
+		models.CategoryError:   {MaxAttempts: 3, BaseDelay: "10ms", Multiplier: 2},
+		models.CategoryTimeout: {MaxAttempts: 2, Timeout: "2s"},
+	}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := models.RunConfig{Timeout: 500 * time.Millisecond, Retries: 5, MaxOutputKB: 64}
+
+	tests := []struct {
+		name     string
+		output   string
+		err      error
+		calls    int
+		category string
+	}{
+		{"error retried twice", "segfault", nil, 3, models.CategoryError},
+		{"auth never retried", "401 unauthorized", nil, 1, models.CategoryAuthFailed},
+		{"timeout retried once", "", context.DeadlineExceeded, 2, models.CategoryTimeout},
+	}
+
+	for _, tt := range tests {
+		var timeouts []time.Duration
+		p := ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+			deadline, _ := ctx.Deadline()
+			timeouts = append(timeouts, time.Until(deadline).Round(100*time.Millisecond))
+			return Response{Output: tt.output, ExitCode: 1}, tt.err
+		})
+
+		res := TestModel(p, "acme/model", run, compiled)
+		if len(timeouts) != tt.calls || res.Category != tt.category {
+			t.Errorf("%s: expected %d calls and %s, got %d and %s", tt.name, tt.calls, tt.category, len(timeouts), res.Category)
+		}
+		if tt.category == models.CategoryTimeout && len(timeouts) == 2 &&
+			(timeouts[0] != 500*time.Millisecond || timeouts[1] != 2*time.Second) {
+			t.Errorf("%s: expected the retry to use the policy timeout, got %v", tt.name, timeouts)
+		}
+	}
+}
+
+func TestTestModelKBTimeout(t *testing.T) {
+	cfg := kb.DefaultConfig()
+	cfg.Timeouts = []kb.TimeoutRule{{Models: []string{"*reasoner*"}, Timeout: "3s"}}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var timeout time.Duration
+	p := ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+		deadline, _ := ctx.Deadline()
+		timeout = time.Until(deadline).Round(100 * time.Millisecond)
+		return Response{ExitCode: 124}, context.DeadlineExceeded
+	})
+	run := models.RunConfig{Timeout: time.Second, MaxOutputKB: 64}
+
+	res := TestModel(p, "acme/deep-reasoner", run, compiled)
+	if timeout != 3*time.Second {
+		t.Errorf("Expected the KB timeout of 3s, probe got %s", timeout)
+	}
+	if res.Category != models.CategoryTimeout || res.Reason != "Timeout (3s)" {
+		t.Errorf("Expected TIMEOUT with the effective timeout, got %s (%s)", res.Category, res.Reason)
+	}
+
+	res = TestModel(p, "acme/flash", run, compiled)
+	if timeout != time.Second || res.Reason != "Timeout (1s)" {
+		t.Errorf("Expected the run timeout for unmatched models, got %s (%s)", timeout, res.Reason)
+	}
+}
+
+func TestTestModelRateLimitUsesRunRetries(t *testing.T) {
+	calls := 0
+	p := ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+		calls++
+		return Response{Output: "429 too many requests", ExitCode: 1}, nil
+	})
+	cfg := models.RunConfig{Timeout: time.Second, Retries: 2, MaxOutputKB: 64}
+
+	if res := TestModel(p, "acme/model", cfg, testKB(t)); res.Category != models.CategoryRateLimited || calls != 3 {
+		t.Errorf("Expected 3 attempts ending RATE_LIMITED, got %d (%s)", calls, res.Category)
+	}
+}
+
+func TestTestModelRecordsAttempts(t *testing.T) {
+	cfg := kb.DefaultConfig()
+	cfg.RetryPolicies = map[string]kb.RetryPolicy{
+		models.CategoryRateLimited: {MaxAttempts: 3, BaseDelay: "10ms", Multiplier: 2},
+	}
+	compiled, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	p := ProberFunc(func(ctx context.Context, model string, cfg models.RunConfig) (Response, error) {
+		calls++
+		if calls < 3 {
+			return Response{Output: "429 too many requests", ExitCode: 1, Duration: 5 * time.Millisecond}, nil
+		}
+		return Response{Output: "2, 3, 5", Duration: 20 * time.Millisecond}, nil
+	})
+	run := models.RunConfig{Timeout: time.Second, MaxOutputKB: 64}
+
+	res := TestModel(p, "acme/model", run, compiled)
+	if len(res.Attempts) != 3 {
+		t.Fatalf("Expected 3 recorded attempts, got %+v", res.Attempts)
+	}
+	want := []models.Attempt{
+		{Category: models.CategoryRateLimited, ExitCode: 1, DurationMs: 5, BackoffMs: 10, Output: "429 too many requests"},
+		{Category: models.CategoryRateLimited, ExitCode: 1, DurationMs: 5, BackoffMs: 20, Output: "429 too many requests"},
+		{Category: models.CategoryAvailable, DurationMs: 20, Output: "2, 3, 5"},
+	}
+	for i, a := range res.Attempts {
+		if a != want[i] {
+			t.Errorf("Attempt %d: got %+v, want %+v", i, a, want[i])
+		}
+	}
+	if res.Category != models.CategoryAvailable || res.DurationMs != 20 {
+		t.Errorf("Result should reflect the last attempt, got %s in %dms", res.Category, res.DurationMs)
+	}
+}
@@ -5,15 +5,23 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"llm-radar/internal/checkpoint"
+	"llm-radar/internal/config"
+	"llm-radar/internal/export"
+	"llm-radar/internal/filter"
+	"llm-radar/internal/headless"
+	"llm-radar/internal/junit"
 	"llm-radar/internal/kb"
 	"llm-radar/internal/models"
+	"llm-radar/internal/policy"
 	"llm-radar/internal/tui"
+	"llm-radar/internal/worker"
 )
 
 // ============================================================================
@@ -29,34 +37,124 @@ const (
 // Build variables (injected via ldflags)
 var BuildTime = "dev"
 
+// stringList is a flag value that accumulates repeated or comma-separated
+// values. Regex patterns ("re:...") are kept whole since they may contain commas.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	if strings.HasPrefix(value, filter.RegexPrefix) {
+		*l = append(*l, value)
+		return nil
+	}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// optionalValue is a flag that may be given bare (--resume) or with a
+// value (--resume=ID). A bare flag followed by an argument takes it as the
+// value (see parseFlags).
+type optionalValue struct {
+	set   bool
+	value string
+}
+
+func (o *optionalValue) String() string { return o.value }
+
+func (o *optionalValue) Set(value string) error {
+	o.set = true
+	if value != "true" {
+		o.value = value
+	}
+	return nil
+}
+
+func (o *optionalValue) IsBoolFlag() bool { return true }
+
+// parseFlags parses args into fs. Since --resume is a bool-style flag,
+// parsing stops at a run ID given as "--resume ID"; the ID is taken as the
+// flag's value and the flags after it are parsed too. It returns the
+// remaining arguments.
+func parseFlags(fs *flag.FlagSet, resume *optionalValue, args []string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	rest := fs.Args()
+	if resume.set && resume.value == "" && len(rest) > 0 {
+		resume.value = rest[0]
+		if err := fs.Parse(rest[1:]); err != nil {
+			return nil, err
+		}
+		rest = fs.Args()
+	}
+	return rest, nil
+}
+
 // ============================================================================
 // MAIN
 // ============================================================================
 
 func main() {
-	parallel := flag.Int("c", 0, "Número de workers paralelos (0 = automático)")
-	timeoutFlag := flag.Duration("t", 20*time.Second, "Timeout por modelo")
+	flag.Int("c", 0, "Número de workers paralelos (0 = automático)")
+	flag.Duration("t", 20*time.Second, "Timeout por modelo")
+	flag.Duration("deadline", 0, "Prazo total dos testes; modelos restantes viram SKIPPED (0 = sem prazo)")
+	flag.String("probe", "default", "Probe nomeado da KB (prompt e critério de sucesso)")
 	refresh := flag.Bool("refresh", false, "Atualizar lista de modelos")
 	kbFile := flag.String("kb", "", "Arquivo JSON com KB customizada")
-	useCache := flag.Bool("cache", false, "Usar cache (válido 24h)")
+	flag.Bool("cache", false, "Usar cache (TTL por categoria, padrão 24h)")
+	cacheStats := flag.Bool("cache-stats", false, "Mostrar estatísticas do cache ao final")
 	version := flag.Bool("version", false, "Mostrar versão")
+	headlessMode := flag.Bool("headless", false, "Executar sem TUI, emitindo resultados em stdout")
+	format := flag.String("format", headless.FormatNDJSON, "Formato de saída no modo headless (ndjson|json)")
+	policyExpr := flag.String("policy", "", "Regras de CI, ex.: \"FREE>=2,model:opencode/big-pickle\"")
+	policyFile := flag.String("policy-file", "", "Arquivo JSON com a política de CI")
+	junitPath := flag.String("junit", "", "Gravar relatório JUnit XML neste caminho")
+	flag.String("serve", "", "Reusar um único opencode serve (auto ou URL de um servidor)")
+	flag.Int("samples", 1, "Probes por modelo (mais de 1 mede taxa de sucesso e latência)")
+	flag.Duration("sample-interval", time.Second, "Intervalo mínimo entre amostras do mesmo modelo")
+	flag.Int("circuit-breaker", 2, "Falhas seguidas de auth/cota que pulam o restante do provedor (0 = desligado)")
+	flag.String("export", "", "Formato de exportação (csv|md|html|json)")
+	flag.String("output", "", "Caminho do arquivo exportado (padrão: ~/.config/opencode/results)")
+	modelsFile := flag.String("models-file", "", "Arquivo com a lista de modelos (- para stdin), sem descoberta")
+	var providers, include, exclude stringList
+	flag.Var(&providers, "provider", "Testar apenas estes provedores (repetível ou separado por vírgula)")
+	flag.Var(&include, "include", "Incluir modelos por glob ou re:regex (repetível)")
+	flag.Var(&exclude, "exclude", "Excluir modelos por glob ou re:regex (repetível)")
+	var resume optionalValue
+	flag.Var(&resume, "resume", "Retomar uma execução interrompida (a mais recente ou o run-id dado)")
 
-	flag.Parse()
+	args, _ := parseFlags(flag.CommandLine, &resume, os.Args[1:])
 
 	if *version {
 		fmt.Printf("%s v%s\n", AppName, Version)
 		os.Exit(0)
 	}
 
-	concurrency := *parallel
-	if concurrency <= 0 {
-		concurrency = runtime.NumCPU()
-		if concurrency > 8 { // Cap to avoid overwhelming
-			concurrency = 8
-		}
-		if concurrency < 2 {
-			concurrency = 2
+	resolved, err := resolveConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Erro na configuração: %v\n", err)
+		os.Exit(policy.ExitUsage)
+	}
+
+	if len(args) > 0 {
+		if len(args) == 2 && args[0] == "config" && args[1] == "show" {
+			resolved.Show(os.Stdout)
+			os.Exit(0)
 		}
+		fmt.Fprintf(os.Stderr, "❌ Comando desconhecido: %s (disponível: config show)\n", strings.Join(args, " "))
+		os.Exit(policy.ExitUsage)
+	}
+
+	runCfg := resolved.Config
+	if runCfg.Concurrency <= 0 {
+		runCfg.Concurrency = autoConcurrency()
 	}
 
 	compiledKB, err := kb.LoadAndCompile(*kbFile)
@@ -65,30 +163,273 @@ func main() {
 		os.Exit(1)
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	cachePath := filepath.Join(homeDir, ".config", "opencode", "cache", "results.json")
+	runsDir := checkpoint.DefaultDir()
+	var resumeRun *checkpoint.Run
+	if resume.set {
+		if *modelsFile != "" {
+			fmt.Fprintln(os.Stderr, "❌ --resume não pode ser combinado com --models-file")
+			os.Exit(policy.ExitUsage)
+		}
+		if resumeRun, err = loadResume(runsDir, resume.value, compiledKB); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(policy.ExitUsage)
+		}
+		// The resumed run keeps its original prompt and probe.
+		runCfg.Probe = resumeRun.Probe
+	}
 
-	runCfg := models.RunConfig{
-		Prompt:      "Escreva apenas: 2, 3, 5",
-		Timeout:     *timeoutFlag,
-		Concurrency: concurrency,
-		Retries:     1,
-		MaxOutputKB: 64,
-		UseCache:    *useCache,
-		CachePath:   cachePath,
+	probe, err := compiledKB.UseProbe(runCfg.Probe)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(policy.ExitUsage)
+	}
+	runCfg.Probe = probe.Name
+	if probe.Prompt != "" {
+		runCfg.Prompt = probe.Prompt
+	}
+	if resumeRun != nil {
+		runCfg.Prompt = resumeRun.Prompt
+	}
+
+	if runCfg.ExportFormat != "" {
+		if _, err := export.Get(runCfg.ExportFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v (use %s)\n", err, strings.Join(export.Formats(), ", "))
+			os.Exit(policy.ExitUsage)
+		}
+	}
+
+	if _, err := filter.New(runCfg.Providers, runCfg.Include, runCfg.Exclude); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Erro no filtro: %v\n", err)
+		os.Exit(policy.ExitUsage)
+	}
+
+	var modelList []string
+	if *modelsFile != "" {
+		if modelList, err = worker.LoadModelList(*modelsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(policy.ExitUsage)
+		}
+	}
+
+	runPolicy, err := loadPolicy(*policyExpr, *policyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Erro na política: %v\n", err)
+		os.Exit(policy.ExitUsage)
+	}
+
+	if runCfg.UseCache {
+		runCfg.OpencodeVersion = worker.DetectOpencodeVersion()
 	}
 
 	if *refresh {
-		fmt.Println("🔄 Atualizando lista de modelos...")
+		fmt.Fprintln(os.Stderr, "🔄 Atualizando lista de modelos...")
 		if err := exec.Command("opencode", "models", "--refresh").Run(); err != nil {
 			fmt.Fprintf(os.Stderr, "⚠️  Aviso: falha ao atualizar: %v\n", err)
 		}
 	}
 
+	if *headlessMode {
+		results := runHeadless(runCfg, compiledKB, modelList, *format, *cacheStats, runsDir, resumeRun)
+		os.Exit(gate(results, runPolicy, *junitPath))
+	}
+
 	model := tui.NewAppModel(runCfg, compiledKB, AppName, Version, CacheExpiry)
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	programOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	if *modelsFile != "" {
+		model.SetModelList(modelList)
+	}
+	model.SetCheckpoint(runsDir, resumeRun)
+	if *modelsFile == "-" {
+		// Stdin was consumed by the model list; read keys from the terminal.
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(model, programOpts...)
+	_, err = p.Run()
+	// Quitting mid-run stops the workers from Update; this covers the
+	// program ending any other way.
+	model.Stop()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Erro TUI: %v\n", err)
 		os.Exit(1)
 	}
+	if aborted, skipped := model.Interrupted(); aborted+skipped > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Execução interrompida: %d probes abortadas, %d modelos não testados\n", aborted, skipped)
+		if id := model.RunID(); id != "" {
+			fmt.Fprintf(os.Stderr, "💾 Progresso salvo; retome com: llm-radar --resume %s\n", id)
+		}
+	}
+
+	if *cacheStats {
+		fmt.Printf("📦 Cache: %s\n", model.CacheStats())
+	}
+
+	os.Exit(gate(model.Results(), runPolicy, *junitPath))
+}
+
+// flagKeys maps command-line flags to their config keys.
+var flagKeys = map[string]string{
+	"c":               "concurrency",
+	"t":               "timeout",
+	"deadline":        "deadline",
+	"probe":           "probe",
+	"cache":           "cache",
+	"serve":           "serve",
+	"circuit-breaker": "circuit_breaker",
+	"samples":         "samples",
+	"sample-interval": "sample_interval",
+	"export":          "export",
+	"output":          "output",
+	"provider":        "providers",
+	"include":         "include",
+	"exclude":         "exclude",
+}
+
+// resolveConfig merges defaults, the user and project config files,
+// LLM_RADAR_* environment variables and explicitly set flags, in
+// increasing order of precedence.
+func resolveConfig() (config.Resolved, error) {
+	userLayer, err := config.LoadFile(config.UserConfigPath(), config.SourceUser)
+	if err != nil {
+		return config.Resolved{}, err
+	}
+
+	cwd, _ := os.Getwd()
+	projectLayer, err := config.LoadFile(config.FindProjectConfig(cwd), config.SourceProject)
+	if err != nil {
+		return config.Resolved{}, err
+	}
+
+	flagLayer := config.Layer{}
+	flag.Visit(func(f *flag.Flag) {
+		key, ok := flagKeys[f.Name]
+		if !ok {
+			return
+		}
+		var value any = f.Value.String()
+		if list, ok := f.Value.(*stringList); ok {
+			value = []string(*list)
+		}
+		flagLayer[key] = config.Setting{
+			Value:  value,
+			Origin: config.Origin{Source: config.SourceFlag, Detail: "-" + f.Name},
+		}
+	})
+
+	return config.Resolve(userLayer, projectLayer, config.FromEnv(os.Environ()), flagLayer)
+}
+
+// autoConcurrency picks a worker count from the number of CPUs.
+func autoConcurrency() int {
+	concurrency := runtime.NumCPU()
+	if concurrency > 8 { // Cap to avoid overwhelming
+		concurrency = 8
+	}
+	if concurrency < 2 {
+		concurrency = 2
+	}
+	return concurrency
+}
+
+// runHeadless probes all models without the TUI and writes results to stdout.
+func runHeadless(runCfg models.RunConfig, compiledKB kb.Compiled, modelList []string, format string, cacheStats bool, runsDir string, resumeRun *checkpoint.Run) []models.ModelResult {
+	if !headless.ValidFormat(format) {
+		fmt.Fprintf(os.Stderr, "❌ Formato inválido: %q (use ndjson ou json)\n", format)
+		os.Exit(policy.ExitUsage)
+	}
+
+	report, err := headless.Run(os.Stdout, runCfg, compiledKB, headless.Options{
+		Format:        format,
+		AppName:       AppName,
+		Version:       Version,
+		CacheExpiry:   CacheExpiry,
+		Models:        modelList,
+		CheckpointDir: runsDir,
+		Resume:        resumeRun,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Erro: %v\n", err)
+		os.Exit(1)
+	}
+
+	if report.Interrupted {
+		fmt.Fprintf(os.Stderr, "⚠️  Execução interrompida: %d modelos com resultado\n", len(report.Results))
+		if report.RunID != "" {
+			fmt.Fprintf(os.Stderr, "💾 Progresso salvo; retome com: llm-radar --resume %s\n", report.RunID)
+		}
+	}
+
+	if cacheStats && report.CacheStats != nil {
+		fmt.Fprintf(os.Stderr, "📦 Cache: %s\n", *report.CacheStats)
+	}
+
+	if n := report.Summary.Categories[models.CategorySkipped]; n > 0 && runCfg.Deadline > 0 {
+		fmt.Fprintf(os.Stderr, "⏰ Prazo de %s esgotado: %d modelos não testados\n", runCfg.Deadline, n)
+	}
+
+	if runCfg.ExportFormat != "" || runCfg.ExportPath != "" {
+		exportFormat := runCfg.ExportFormat
+		if exportFormat == "" {
+			exportFormat = export.FormatJSON
+		}
+		path := export.ResolvePath(runCfg.ExportPath, exportFormat, time.Now())
+		if err := export.WriteFile(path, exportFormat, report.Document); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Erro ao exportar: %v\n", err)
+			os.Exit(policy.ExitError)
+		}
+		fmt.Fprintf(os.Stderr, "💾 Exportado para %s\n", path)
+	}
+
+	return report.Results
+}
+
+// loadResume loads the checkpoint of run id (the latest when empty) and
+// checks that it was produced with the current KB.
+func loadResume(dir, id string, compiledKB kb.Compiled) (*checkpoint.Run, error) {
+	run, err := checkpoint.Load(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	if run.KBHash != compiledKB.Fingerprint() {
+		return nil, fmt.Errorf("a KB mudou desde a execução %s; não é possível retomar", run.RunID)
+	}
+	fmt.Fprintf(os.Stderr, "↩️  Retomando %s: %d concluídos, %d restantes\n", run.RunID, len(run.Results), len(run.Remaining()))
+	return &run, nil
+}
+
+// loadPolicy combines the policy file and the inline policy expression.
+// Inline rules override rules from the file.
+func loadPolicy(expr, path string) (policy.Policy, error) {
+	var filePolicy policy.Policy
+	if path != "" {
+		var err error
+		if filePolicy, err = policy.Load(path); err != nil {
+			return policy.Policy{}, err
+		}
+	}
+
+	inline, err := policy.Parse(expr)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+
+	return filePolicy.Merge(inline), nil
+}
+
+// gate writes the JUnit report and evaluates the policy, returning the
+// process exit code.
+func gate(results []models.ModelResult, runPolicy policy.Policy, junitPath string) int {
+	violations := runPolicy.Evaluate(models.Summarize(results), results)
+
+	if junitPath != "" {
+		if err := junit.WriteFile(junitPath, AppName, results, violations); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Erro ao gravar JUnit: %v\n", err)
+			return policy.ExitError
+		}
+	}
+
+	for _, v := range violations {
+		fmt.Fprintf(os.Stderr, "🚫 Política violada [%s]: %s\n", v.Rule, v.Message)
+	}
+
+	return policy.ExitCode(violations)
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestParseFlagsResume(t *testing.T) {
+	tests := []struct {
+		args     []string
+		id       string
+		headless bool
+		rest     int
+	}{
+		{[]string{"--resume"}, "", false, 0},
+		{[]string{"--resume", "abc"}, "abc", false, 0},
+		{[]string{"--resume=abc", "--headless"}, "abc", true, 0},
+		{[]string{"--headless", "--resume", "abc"}, "abc", true, 0},
+		{[]string{"--resume", "abc", "--headless"}, "abc", true, 0},
+		{[]string{"--resume", "abc", "extra"}, "abc", false, 1},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("llm-radar", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		headless := fs.Bool("headless", false, "")
+		var resume optionalValue
+		fs.Var(&resume, "resume", "")
+
+		rest, err := parseFlags(fs, &resume, tt.args)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", tt.args, err)
+			continue
+		}
+		if !resume.set || resume.value != tt.id || *headless != tt.headless || len(rest) != tt.rest {
+			t.Errorf("%v: got id %q headless %v rest %v", tt.args, resume.value, *headless, rest)
+		}
+	}
+}
@@ -132,7 +132,7 @@ func TestIntegration_ClassificationPipeline(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := classifier.Classify(tt.modelName, tt.exitCode, tt.output, compiledKB)
+			result := classifier.Classify(tt.modelName, tt.exitCode, tt.output, 20*time.Second, compiledKB)
 
 			if result.Category != tt.expectCat {
 				t.Errorf("Expected category %s, got %s", tt.expectCat, result.Category)
@@ -160,7 +160,17 @@ func TestIntegration_ModelPrioritization(t *testing.T) {
 		"groq/llama3-8b-free": true,
 	}
 
-	prioritized := worker.PrioritizeModels(models, freeModels, "zai-coding-plan/")
+	cfg := kb.DefaultConfig()
+	cfg.FreeModels = make(map[string]kb.ModelInfo)
+	for m := range freeModels {
+		cfg.FreeModels[m] = kb.ModelInfo{Category: "FREE"}
+	}
+	compiledKB, err := kb.Compile(cfg)
+	if err != nil {
+		t.Fatalf("Failed to compile KB: %v", err)
+	}
+
+	prioritized := worker.PrioritizeByKB(models, compiledKB, nil)
 
 	// Verify free models come first
 	if prioritized[0] != "google/gemini-flash" && prioritized[0] != "groq/llama3-8b-free" {
@@ -217,17 +227,21 @@ fi
 	defer os.Setenv("PATH", oldPath)
 
 	// Test discovery
-	cmd := worker.DiscoverModelsCmd()
+	compiledKB, err := kb.LoadAndCompile("")
+	if err != nil {
+		t.Fatalf("Failed to load KB: %v", err)
+	}
+	cmd := worker.DiscoverModelsCmd(compiledKB)
 	msg := cmd()
 
 	switch result := msg.(type) {
-	case []string:
-		if len(result) != 3 {
-			t.Errorf("Expected 3 valid models, got %d", len(result))
+	case worker.DiscoveryResult:
+		if len(result.Models) != 3 {
+			t.Errorf("Expected 3 valid models, got %d", len(result.Models))
 		}
 
 		// Verify invalid lines were filtered out
-		for _, model := range result {
+		for _, model := range result.Models {
 			if model == "invalid-line-without-slash" {
 				t.Errorf("Invalid model should have been filtered out")
 			}
@@ -257,11 +271,7 @@ func TestIntegration_EndToEnd(t *testing.T) {
 	}
 
 	// 3. Prioritize models
-	freeModels := map[string]bool{
-		"google/gemini-flash": true,
-		"groq/llama3-8b":     true,
-	}
-	prioritized := worker.PrioritizeModels(modelList, freeModels, "zai-")
+	prioritized := worker.PrioritizeByKB(modelList, compiledKB, nil)
 
 	if len(prioritized) != len(modelList) {
 		t.Errorf("Prioritization changed model count")
@@ -270,7 +280,7 @@ func TestIntegration_EndToEnd(t *testing.T) {
 	// 4. Simulate testing models (without actual execution)
 	for _, model := range prioritized {
 		// Simulate a simple classification
-		result := classifier.Classify(model, 0, "2, 3, 5", compiledKB)
+		result := classifier.Classify(model, 0, "2, 3, 5", 20*time.Second, compiledKB)
 
 		if result.Category == "" {
 			t.Errorf("Model %s classification failed", model)